@@ -0,0 +1,117 @@
+package bc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ActionNamespace is the namespace of the bound actions of the BC APIs.
+const ActionNamespace = "Microsoft.NAV."
+
+// actionPath returns the name with the ActionNamespace prefix. Names that
+// are already qualified, e.g. by a custom namespace, are returned as is.
+func actionPath(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return ActionNamespace + name
+}
+
+// Action makes a POST request to the bound action of the record, e.g. "post"
+// for "salesInvoices({id})/Microsoft.NAV.post". The body is optional and
+// any response body is discarded, use [CallAction] for actions that return a result.
+// Errors raised by the action, like a posting error, are returned as an [APIError]
+// matching [ErrValidationFailure].
+func (a *APIPage[T]) Action(ctx context.Context, id uuid.UUID, name string, body any) error {
+	req, err := a.newActionRequest(ctx, id, name, body)
+	if err != nil {
+		return err
+	}
+
+	a.client.logger.Debug("Sending request...", "url", req.URL.String(), "method", req.Method)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed during request: %w", err)
+	}
+
+	// Usually a 204 No Content
+	err = DecodeNoContent(res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			a.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	a.client.logger.Debug("Successfully called action.", "id", id, "action", name)
+
+	return nil
+}
+
+// CallAction makes a POST request to the bound action of the record like
+// [APIPage.Action] and decodes the result into R. Actions that return
+// a primitive or a collection wrap it in a "value" field.
+func CallAction[R Validator, T Validator](ctx context.Context, a *APIPage[T], id uuid.UUID, name string, body any) (R, error) {
+	var v R
+
+	req, err := a.newActionRequest(ctx, id, name, body)
+	if err != nil {
+		return v, err
+	}
+
+	a.client.logger.Debug("Sending request...", "url", req.URL.String(), "method", req.Method)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return v, fmt.Errorf("failed during request: %w", err)
+	}
+
+	if res.StatusCode == http.StatusNoContent {
+		res.Body.Close()
+		return v, fmt.Errorf("action %s returned no content", actionPath(name))
+	}
+
+	v, err = Decode[R](res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			a.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return v, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return v, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return v, nil
+}
+
+func (a *APIPage[T]) newActionRequest(ctx context.Context, id uuid.UUID, name string, body any) (*http.Request, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("action: id is empty")
+	}
+	if name == "" {
+		return nil, errors.New("action: name is empty")
+	}
+
+	opts := RequestOptions{
+		Method:        http.MethodPost,
+		EntitySetName: a.entitySetName,
+		RecordID:      id,
+		Path:          actionPath(name),
+		Body:          body,
+	}
+	req, err := a.client.NewRequest(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+	return req, nil
+}
@@ -0,0 +1,137 @@
+package bc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/google/uuid"
+)
+
+type fakeInvoice struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func (f fakeInvoice) Validate() error {
+	return nil
+}
+
+type fakeActionResult struct {
+	Value string `json:"value"`
+}
+
+func (f fakeActionResult) Validate() error {
+	return nil
+}
+
+func newActionServer(t *testing.T) (*bc.APIPage[fakeInvoice], uuid.UUID) {
+	t.Helper()
+
+	srv := bcfake.NewServer()
+	t.Cleanup(srv.Close)
+
+	id, err := srv.Insert("salesInvoices", fakeInvoice{Status: "Draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AddAction("salesInvoices", "post", func(record, body map[string]any) (any, error) {
+		if record["status"] != "Draft" {
+			return nil, &bcfake.Error{StatusCode: http.StatusBadRequest, Code: "Application_DialogException", Message: "The invoice is already posted."}
+		}
+		record["status"] = "Open"
+		return nil, nil
+	})
+	srv.AddAction("salesInvoices", "Contoso.getNumber", func(record, body map[string]any) (any, error) {
+		return map[string]any{"value": "INV-" + body["prefix"].(string)}, nil
+	})
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc.NewAPIPage[fakeInvoice](client, "salesInvoices"), id
+}
+
+func TestAction(t *testing.T) {
+	page, id := newActionServer(t)
+	ctx := context.Background()
+
+	if err := page.Action(ctx, id, "post", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	invoice, err := page.Get(ctx, id, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Status != "Open" {
+		t.Errorf("wanted status Open, got %s", invoice.Status)
+	}
+
+	// Posting again fails in the action
+	err = page.Action(ctx, id, "post", nil)
+	if !bc.IsValidationFailure(err) {
+		t.Errorf("wanted validation failure, got %v", err)
+	}
+
+	err = page.Action(ctx, id, "unknown", nil)
+	if !bc.IsNotFound(err) {
+		t.Errorf("wanted not found, got %v", err)
+	}
+
+	if err := page.Action(ctx, uuid.Nil, "post", nil); err == nil {
+		t.Error("expected error for empty id, got nil")
+	}
+}
+
+func TestCallAction(t *testing.T) {
+	page, id := newActionServer(t)
+	ctx := context.Background()
+
+	result, err := bc.CallAction[fakeActionResult](ctx, page, id, "Contoso.getNumber", map[string]any{"prefix": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "INV-1" {
+		t.Errorf("wanted INV-1, got %s", result.Value)
+	}
+
+	// post returns no content
+	_, err = bc.CallAction[fakeActionResult](ctx, page, id, "post", nil)
+	if err == nil {
+		t.Error("expected error for no content, got nil")
+	}
+	var apiErr bc.APIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("wanted a non API error, got %v", err)
+	}
+}
+
+func TestRequestOptionsPath(t *testing.T) {
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := uuid.New()
+	req, err := client.NewRequest(context.Background(), bc.RequestOptions{
+		Method:        http.MethodPost,
+		EntitySetName: "salesOrders",
+		RecordID:      id,
+		Path:          "Microsoft.NAV.shipAndInvoice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/salesOrders(" + id.String() + ")/Microsoft.NAV.shipAndInvoice"
+	if got := req.URL.Path; !strings.HasSuffix(got, want) {
+		t.Errorf("wanted path ending in %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,247 @@
+package bc
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminAPIVersion is the version of the admin center API used by the AdminClient.
+const AdminAPIVersion = "v2.24"
+
+// The types of an Environment.
+const (
+	EnvironmentProduction = "Production"
+	EnvironmentSandbox    = "Sandbox"
+)
+
+// EnvironmentActive is the status of an Environment that can be used.
+const EnvironmentActive = "Active"
+
+// AdminClient sends requests to the BC admin center API to discover the
+// environments of a tenant and the apps installed in them.
+type AdminClient struct {
+	client *Client
+}
+
+// NewAdminClient creates an [AdminClient]. The tokenGetter must get tokens for the tenant
+// with [DefaultScope], which is also the scope of the admin center API, e.g. from [NewAuth].
+// The apiHost defaults to DefaultAPIHost if empty. The [ClientOption] functions are the
+// same as for [NewClient], so [WithHTTPClient] can be used to mock the API.
+func NewAdminClient(tokenGetter TokenGetter, apiHost string, opts ...ClientOption) (*AdminClient, error) {
+	if tokenGetter == nil {
+		return nil, errors.New("create admin client: tokenGetter is nil")
+	}
+
+	host := strings.TrimSuffix(cmp.Or(apiHost, DefaultAPIHost), "/")
+	if err := validateHost(host); err != nil {
+		return nil, fmt.Errorf("create admin client: APIHost: %w", err)
+	}
+
+	baseURL, err := url.Parse(host + "/admin/" + AdminAPIVersion + "/applications/BusinessCentral")
+	if err != nil {
+		return nil, fmt.Errorf("create admin client: %w", err)
+	}
+
+	client := &Client{}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	client.authClient = tokenGetter
+	client.baseURL = baseURL
+	client.logger = cmp.Or(client.logger, slog.Default())
+	client.baseClient = cmp.Or(client.baseClient, &http.Client{Timeout: 20 * time.Second})
+
+	return &AdminClient{client: client}, nil
+}
+
+// Environment is a BC environment of the tenant, as returned by the admin center API.
+type Environment struct {
+	Name               string    `json:"name"`
+	FriendlyName       string    `json:"friendlyName"`
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	CountryCode        string    `json:"countryCode"`
+	ApplicationFamily  string    `json:"applicationFamily"`
+	AADTenantID        uuid.UUID `json:"aadTenantId"`
+	ApplicationVersion string    `json:"applicationVersion"`
+	PlatformVersion    string    `json:"platformVersion"`
+	RingName           string    `json:"ringName"`
+	LocationName       string    `json:"locationName"`
+	WebClientLoginURL  string    `json:"webClientLoginUrl"`
+	WebServiceURL      string    `json:"webServiceUrl"`
+}
+
+// Validate implements the Validator interface.
+func (e Environment) Validate() error {
+	if e.Name == "" {
+		return errors.New("environment name is empty")
+	}
+	return nil
+}
+
+// IsProduction returns true if it is a production environment.
+func (e Environment) IsProduction() bool {
+	return e.Type == EnvironmentProduction
+}
+
+// IsSandbox returns true if it is a sandbox environment.
+func (e Environment) IsSandbox() bool {
+	return e.Type == EnvironmentSandbox
+}
+
+// InstalledApp is an app installed in an environment.
+type InstalledApp struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Publisher        string    `json:"publisher"`
+	Version          string    `json:"version"`
+	State            string    `json:"state"`
+	AppType          string    `json:"appType"`
+	CanBeUninstalled bool      `json:"canBeUninstalled"`
+}
+
+// Validate implements the Validator interface.
+func (a InstalledApp) Validate() error {
+	if a.ID == uuid.Nil {
+		return errors.New("app id is empty")
+	}
+	return nil
+}
+
+// adminListResponse is the response body of the admin center API lists.
+type adminListResponse[T Validator] struct {
+	Value []T `json:"value"`
+}
+
+// Validate implements the Validator interface. It validates each row.
+func (r adminListResponse[T]) Validate() error {
+	for i, v := range r.Value {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("value %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// adminErrorResponse is the error body of the admin center API. It is usually
+// flat but the OData format with an "error" object is accepted too.
+type adminErrorResponse struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Error   *ErrorResponseError `json:"error"`
+}
+
+// Environments makes a GET request for all the environments of the tenant.
+func (a *AdminClient) Environments(ctx context.Context) ([]Environment, error) {
+	return adminList[Environment](ctx, a, "/environments")
+}
+
+// Environment makes a GET request for the environment with the name.
+func (a *AdminClient) Environment(ctx context.Context, name string) (Environment, error) {
+	if name == "" {
+		return Environment{}, errors.New("environment name is empty")
+	}
+	return adminGet[Environment](ctx, a, "/environments/"+url.PathEscape(name))
+}
+
+// InstalledApps makes a GET request for the apps installed in the environment.
+func (a *AdminClient) InstalledApps(ctx context.Context, environment string) ([]InstalledApp, error) {
+	if environment == "" {
+		return nil, errors.New("environment name is empty")
+	}
+	return adminList[InstalledApp](ctx, a, "/environments/"+url.PathEscape(environment)+"/apps")
+}
+
+// ValidateConfig checks the ClientConfig against the tenant. The environment must
+// exist and be active. The ClientConfig itself is not validated.
+func (a *AdminClient) ValidateConfig(ctx context.Context, config ClientConfig) error {
+	env, err := a.Environment(ctx, config.Environment)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) && srvErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("validate config: environment %q does not exist", config.Environment)
+		}
+		return fmt.Errorf("validate config: %w", err)
+	}
+
+	if env.Status != EnvironmentActive {
+		return fmt.Errorf("validate config: environment %q has status %q", env.Name, env.Status)
+	}
+
+	return nil
+}
+
+func adminList[T Validator](ctx context.Context, a *AdminClient, path string) ([]T, error) {
+	v, err := adminGet[adminListResponse[T]](ctx, a, path)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value, nil
+}
+
+// adminGet makes a GET request to the path after the base URL of the AdminClient.
+func adminGet[T Validator](ctx context.Context, a *AdminClient, path string) (T, error) {
+	var v T
+	c := a.client
+
+	reqURL := *c.baseURL
+	reqURL.Path += path
+
+	req, err := c.newRequestURL(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return v, fmt.Errorf("failed to create Request: %w", err)
+	}
+	req.Header.Set("Accept", ContentTypeJSON)
+
+	res, err := c.Do(req)
+	if err != nil {
+		return v, fmt.Errorf("failed during request: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		srvErr := decodeAdminError(res)
+		c.logger.Debug("API server returned error response.", "error", srvErr)
+		return v, fmt.Errorf("error from BC admin API: %w", srvErr)
+	}
+
+	v, err = Decode[T](res)
+	if err != nil {
+		c.logger.Debug("Failed to decode response.", "error", err)
+		return v, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return v, nil
+}
+
+// decodeAdminError decodes the error body into an APIError. If it cannot
+// be decoded the body is used as the message.
+func decodeAdminError(r *http.Response) APIError {
+	defer r.Body.Close()
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return newBCAPIError(r.StatusCode, "", fmt.Sprintf("failed to read Response.Body: %s", err), r.Request)
+	}
+
+	var data adminErrorResponse
+	if err := json.Unmarshal(b, &data); err != nil {
+		return newBCAPIError(r.StatusCode, "", string(b), r.Request)
+	}
+
+	if data.Error != nil {
+		return newBCAPIError(r.StatusCode, data.Error.Code, data.Error.Message, r.Request)
+	}
+	return newBCAPIError(r.StatusCode, data.Code, data.Message, r.Request)
+}
@@ -0,0 +1,133 @@
+package bc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+)
+
+func newFakeAdminClient(t *testing.T) *bc.AdminClient {
+	t.Helper()
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("Authorization") != "Bearer FAKEACCESSTOKEN" {
+			t.Errorf("wanted the Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+
+		prefix := "/admin/" + bc.AdminAPIVersion + "/applications/BusinessCentral/environments"
+		path, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok || r.URL.Host != "admin.test" {
+			t.Fatalf("unexpected URL %s", r.URL)
+		}
+
+		production := map[string]any{"name": "Production", "type": "Production", "status": "Active", "applicationVersion": "26.1.12345.0"}
+		sandbox := map[string]any{"name": "Sandbox", "type": "Sandbox", "status": "Preparing", "applicationVersion": "27.0.1.0"}
+
+		var status int
+		var body any
+		switch path {
+		case "":
+			status, body = 200, map[string]any{"value": []any{production, sandbox}}
+		case "/Production":
+			status, body = 200, production
+		case "/Sandbox":
+			status, body = 200, sandbox
+		case "/Production/apps":
+			status, body = 200, map[string]any{"value": []any{map[string]any{
+				"id":        "63ca2fa4-4f03-4f2b-a480-172fef340d3f",
+				"name":      "System Application",
+				"publisher": "Microsoft",
+				"version":   "26.1.12345.0",
+				"state":     "Installed",
+				"appType":   "Global",
+			}}}
+		default:
+			status, body = 404, map[string]any{"code": "EntityNotFound", "message": "Environment not found."}
+		}
+		return &http.Response{StatusCode: status, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	admin, err := bc.NewAdminClient(fakeTokenGetter{}, "https://admin.test", bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admin
+}
+
+func TestAdminClient(t *testing.T) {
+	admin := newFakeAdminClient(t)
+	ctx := context.Background()
+
+	envs, err := admin.Environments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 2 {
+		t.Fatalf("wanted 2 environments, got %d", len(envs))
+	}
+	if !envs[0].IsProduction() || envs[0].ApplicationVersion != "26.1.12345.0" {
+		t.Errorf("wanted production 26.1.12345.0, got %+v", envs[0])
+	}
+	if !envs[1].IsSandbox() {
+		t.Errorf("wanted sandbox, got %+v", envs[1])
+	}
+
+	apps, err := admin.InstalledApps(ctx, "Production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 1 || apps[0].Name != "System Application" || apps[0].Publisher != "Microsoft" {
+		t.Errorf("wanted System Application, got %+v", apps)
+	}
+
+	_, err = admin.InstalledApps(ctx, "Missing")
+	var apiErr bc.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || apiErr.Code != "EntityNotFound" {
+		t.Errorf("wanted APIError 404 EntityNotFound, got %v", err)
+	}
+}
+
+func TestAdminClientValidateConfig(t *testing.T) {
+	admin := newFakeAdminClient(t)
+
+	tests := []struct {
+		environment string
+		wantErr     string
+	}{
+		{"Production", ""},
+		{"Sandbox", `status "Preparing"`},
+		{"Missing", "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			config := fakeConfig
+			config.Environment = tt.environment
+
+			err := admin.ValidateConfig(context.Background(), config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("wanted no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("wanted error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewAdminClient(t *testing.T) {
+	if _, err := bc.NewAdminClient(nil, ""); err == nil {
+		t.Error("wanted error for nil tokenGetter")
+	}
+	if _, err := bc.NewAdminClient(fakeTokenGetter{}, "admin.test"); err == nil {
+		t.Error("wanted error for host without scheme")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"slices"
 	"strings"
 
+	"github.com/erlorenz/bc-go/bc/filter"
 	"github.com/google/uuid"
 )
 
@@ -24,9 +26,12 @@ type APIPage[T Validator] struct {
 }
 
 // APIListResponse is the response body of a valid GET request that does not
-// have a RecordID. The Value field has a slice of T.
+// have a RecordID. The Value field has a slice of T. NextLink is set when
+// the server has more pages. Count is set when ListOptions.Count is true.
 type APIListResponse[T any] struct {
-	Value []T `json:"value" validate:"required,dive"`
+	Value    []T    `json:"value" validate:"required,dive"`
+	NextLink string `json:"@odata.nextLink,omitempty"`
+	Count    *int   `json:"@odata.count,omitempty"`
 }
 
 // Validate implements the Validator interface. It validates
@@ -51,6 +56,39 @@ func NewAPIPage[T Validator](client *Client, entitySetName string) *APIPage[T] {
 	}
 }
 
+// NewSubPage creates an [APIPage] for the child entity set of a parent record,
+// e.g. the "salesOrderLines" of a sales order. The requests use the path
+// "salesOrders({parentID})/salesOrderLines", so lists and creates are scoped to
+// the parent. The parent can itself be a sub page for deeper nesting.
+// The BaseFilter and BaseExpand of the parent are not inherited.
+// It panics if the parent is nil, the parentID is empty or the navigation is empty.
+func NewSubPage[C Validator, P Validator](parent *APIPage[P], parentID uuid.UUID, navigation string) *APIPage[C] {
+	if parent == nil {
+		panic("create sub page: parent is nil")
+	}
+
+	if parentID == uuid.Nil {
+		panic("create sub page: parentID is empty")
+	}
+
+	if navigation == "" {
+		panic("create sub page: navigation is empty")
+	}
+
+	entitySetName := fmt.Sprintf("%s(%s)/%s", parent.entitySetName, parentID, navigation)
+	return NewAPIPage[C](parent.client, entitySetName)
+}
+
+// EntitySetName returns the entity set name, including the parent for a sub page.
+func (a *APIPage[T]) EntitySetName() string {
+	return a.entitySetName
+}
+
+// Client returns the [Client] of the APIPage.
+func (a *APIPage[T]) Client() *Client {
+	return a.client
+}
+
 // Adds a new string to the baseExpand slice. This will be added
 // to all request expand expressions.
 func (a *APIPage[T]) AddBaseExpand(expand string) {
@@ -58,25 +96,15 @@ func (a *APIPage[T]) AddBaseExpand(expand string) {
 }
 
 // Get makes a GET request to the endpoint and retrieves a single record T.
-// Requires the ID and  takes an optional slice of expand strings.
+// Requires the ID and takes optional expand and select fields.
 func (a *APIPage[T]) Get(ctx context.Context, id uuid.UUID, opts GetOptions) (T, error) {
 	var v T
 
-	qp := QueryParams{}
-
-	// Add new expands to base
-	expands := a.BaseExpand
-	if len(opts.Expand) > 0 {
-		expands = slices.Concat(a.BaseExpand, opts.Expand)
-	}
-
-	qp["$expand"] = strings.Join(expands, ",")
-
 	reqOpts := RequestOptions{
 		Method:        http.MethodGet,
 		EntitySetName: a.entitySetName,
 		RecordID:      id,
-		QueryParams:   qp,
+		QueryParams:   opts.BuildQueryParams(a.BaseExpand),
 	}
 	req, err := a.client.NewRequest(ctx, reqOpts)
 	if err != nil {
@@ -103,45 +131,98 @@ func (a *APIPage[T]) Get(ctx context.Context, id uuid.UUID, opts GetOptions) (T,
 }
 
 // List makes a GET request to the endpoint and returns []T.
-// It takes optional struct of query options.
+// It takes optional struct of query options. It follows the
+// @odata.nextLink of each page until all records are returned
+// or ListOptions.MaxRecords is reached.
 func (a *APIPage[T]) List(ctx context.Context, queryOpts ListOptions) ([]T, error) {
-	var v []T
-
 	qp := queryOpts.BuildQueryParams(a.BaseFilter, a.BaseExpand)
 
 	opts := RequestOptions{
 		Method:        http.MethodGet,
 		EntitySetName: a.entitySetName,
 		QueryParams:   qp,
+		Header:        queryOpts.BuildHeader(),
 	}
 	req, err := a.client.NewRequest(ctx, opts)
 	if err != nil {
-		return v, fmt.Errorf("failed to create Request: %w", err)
+		return nil, fmt.Errorf("failed to create Request: %w", err)
 	}
 
-	res, err := a.client.Do(req)
+	return listAll[T](a.client, req, queryOpts.MaxRecords)
+}
+
+// ListPage makes a single GET request to the endpoint and returns the page of records
+// with the NextLink, and the Count of all matching records if ListOptions.Count is set.
+// Use Top and Skip to page through the records, or pass the NextLink to NextPage.
+// MaxRecords is ignored.
+func (a *APIPage[T]) ListPage(ctx context.Context, queryOpts ListOptions) (APIListResponse[T], error) {
+	opts := RequestOptions{
+		Method:        http.MethodGet,
+		EntitySetName: a.entitySetName,
+		QueryParams:   queryOpts.BuildQueryParams(a.BaseFilter, a.BaseExpand),
+		Header:        queryOpts.BuildHeader(),
+	}
+	req, err := a.client.NewRequest(ctx, opts)
 	if err != nil {
-		return v, fmt.Errorf("failed during request: %w", err)
+		return APIListResponse[T]{}, fmt.Errorf("failed to create Request: %w", err)
 	}
 
-	list, err := Decode[APIListResponse[T]](res)
+	return getPage[T](a.client, req)
+}
+
+// NextPage makes a GET request for the NextLink of a page returned by ListPage.
+// Pass the same ListOptions so the page size is kept.
+func (a *APIPage[T]) NextPage(ctx context.Context, nextLink string, queryOpts ListOptions) (APIListResponse[T], error) {
+	req, err := a.client.newNextLinkRequest(ctx, nextLink, queryOpts.BuildHeader().Get("Prefer"))
 	if err != nil {
-		var srvErr APIError
-		if errors.As(err, &srvErr) {
-			a.client.logger.Debug("API server returned error response.", "error", srvErr)
-			return v, fmt.Errorf("error from BC API: %w", srvErr)
-		}
+		return APIListResponse[T]{}, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	return getPage[T](a.client, req)
+}
+
+// Count makes a GET request to the "$count" of the endpoint and returns the
+// number of records that match the BaseFilter and the Filter of the ListOptions.
+// The other options are ignored.
+func (a *APIPage[T]) Count(ctx context.Context, queryOpts ListOptions) (int, error) {
+	filterString := filter.And(filter.Raw(a.BaseFilter), filter.Raw(queryOpts.Filter)).String()
+	return countRecords(ctx, a.client, a.entitySetName, filterString)
+}
 
-		a.client.logger.Debug("Unable to decode response.", "error", err)
-		return v, fmt.Errorf("decode response: %w", err)
+// All returns an iterator over every record matching the query options.
+// Records are streamed page by page instead of being collected into a slice,
+// so it should be preferred over List for large entity sets.
+// Breaking out of the loop cancels any request still in flight.
+func (a *APIPage[T]) All(ctx context.Context, queryOpts ListOptions) iter.Seq2[T, error] {
+	opts := RequestOptions{
+		Method:        http.MethodGet,
+		EntitySetName: a.entitySetName,
+		QueryParams:   queryOpts.BuildQueryParams(a.BaseFilter, a.BaseExpand),
+		Header:        queryOpts.BuildHeader(),
 	}
-	v = list.Value
-	return v, nil
+
+	return streamAll[T](ctx, a.client, opts, queryOpts.MaxRecords)
 }
 
 // Update makes a Patch request to the endpoint and returns T.
-// It requires a body and a RecordID.
+// It requires a body and a RecordID. It overwrites the record even if it
+// was changed since it was read, use UpdateIfMatch to prevent that.
 func (a *APIPage[T]) Update(ctx context.Context, id uuid.UUID, expand []string, body any) (T, error) {
+	return a.update(ctx, id, "", expand, body)
+}
+
+// UpdateIfMatch makes a Patch request to the endpoint and returns T.
+// It requires a body, a RecordID and the ETag of the record when it was read.
+// If the record has changed since, the error matches [ErrPreconditionFailed].
+func (a *APIPage[T]) UpdateIfMatch(ctx context.Context, id uuid.UUID, etag ETag, expand []string, body any) (T, error) {
+	if etag == "" {
+		var v T
+		return v, errors.New("update: etag is empty")
+	}
+	return a.update(ctx, id, etag, expand, body)
+}
+
+func (a *APIPage[T]) update(ctx context.Context, id uuid.UUID, etag ETag, expand []string, body any) (T, error) {
 	var v T
 
 	qp := QueryParams{}
@@ -164,6 +245,7 @@ func (a *APIPage[T]) Update(ctx context.Context, id uuid.UUID, expand []string,
 		RecordID:      id,
 		QueryParams:   qp,
 		Body:          body,
+		ETag:          etag,
 	}
 	req, err := a.client.NewRequest(ctx, opts)
 	if err != nil {
@@ -246,12 +328,28 @@ func (a *APIPage[T]) Create(ctx context.Context, body any, opts GetOptions) (T,
 }
 
 // Delete makes a DELETE request to the endpoint and returns a string message.
-// It requires a RecordID.
+// It requires a RecordID. It deletes the record even if it was changed since
+// it was read, use DeleteIfMatch to prevent that.
 func (a *APIPage[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	return a.delete(ctx, id, "")
+}
+
+// DeleteIfMatch makes a DELETE request to the endpoint. It requires a RecordID
+// and the ETag of the record when it was read. If the record has changed since,
+// the error matches [ErrPreconditionFailed].
+func (a *APIPage[T]) DeleteIfMatch(ctx context.Context, id uuid.UUID, etag ETag) error {
+	if etag == "" {
+		return errors.New("delete: etag is empty")
+	}
+	return a.delete(ctx, id, etag)
+}
+
+func (a *APIPage[T]) delete(ctx context.Context, id uuid.UUID, etag ETag) error {
 	opts := RequestOptions{
 		Method:        http.MethodDelete,
 		EntitySetName: a.entitySetName,
 		RecordID:      id,
+		ETag:          etag,
 	}
 	req, err := a.client.NewRequest(ctx, opts)
 	if err != nil {
@@ -2,14 +2,17 @@ package bc
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"iter"
 	"net/http"
-	"strconv"
-	"strings"
+	"slices"
+
+	"github.com/erlorenz/bc-go/bc/filter"
 )
 
-// APIQuery interacts with a BC API Query.
+// APIQuery interacts with a BC API Query. It takes the same ListOptions as an
+// [APIPage], including Apply for aggregations. Set BaseFilter and BaseSelect
+// for all requests.
 type APIQuery[T any] struct {
 	entitySetName string
 	client        *Client
@@ -32,63 +35,80 @@ func NewAPIQuery[T any](client *Client, entitySetName string) *APIQuery[T] {
 	}
 }
 
-// List
+// List makes a GET request to the query and returns []T.
+// It follows the @odata.nextLink of each page until all records are
+// returned or ListOptions.MaxRecords is reached.
 func (q *APIQuery[T]) List(ctx context.Context, opts ListOptions) ([]T, error) {
-	var v []T
-
-	filterStrings := []string{}
-	// Add the baseFilter
-	if q.BaseFilter != "" {
-		filterStrings = append(filterStrings, q.BaseFilter)
+	ropts := q.requestOptions(opts)
+	req, err := q.client.NewRequest(ctx, ropts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
 	}
 
-	// Add the filter and surround with "()" if there is a baseFilter
-	if opts.Filter != "" {
-		if q.BaseFilter != "" {
-			opts.Filter = fmt.Sprintf("(%s)", opts.Filter)
-		}
-		filterStrings = append(filterStrings, opts.Filter)
-	}
+	return listAll[T](q.client, req, opts.MaxRecords)
+}
 
-	filter := strings.Join(filterStrings, " and ")
+// All returns an iterator over every record returned by the query.
+// Records are streamed page by page instead of being collected into a slice.
+// Breaking out of the loop cancels any request still in flight.
+func (q *APIQuery[T]) All(ctx context.Context, opts ListOptions) iter.Seq2[T, error] {
+	return streamAll[T](ctx, q.client, q.requestOptions(opts), opts.MaxRecords)
+}
+
+// ListPage makes a single GET request to the query and returns the page of records
+// with the NextLink, and the Count if ListOptions.Count is set. MaxRecords is ignored.
+func (q *APIQuery[T]) ListPage(ctx context.Context, opts ListOptions) (APIListResponse[T], error) {
+	req, err := q.client.NewRequest(ctx, q.requestOptions(opts))
+	if err != nil {
+		return APIListResponse[T]{}, fmt.Errorf("failed to create Request: %w", err)
+	}
 
-	qp := QueryParams{}
+	return getPage[T](q.client, req)
+}
 
-	if filter != "" {
-		qp["$filter"] = filter
+// NextPage makes a GET request for the NextLink of a page returned by ListPage.
+// Pass the same ListOptions so the page size is kept.
+func (q *APIQuery[T]) NextPage(ctx context.Context, nextLink string, opts ListOptions) (APIListResponse[T], error) {
+	req, err := q.client.newNextLinkRequest(ctx, nextLink, opts.BuildHeader().Get("Prefer"))
+	if err != nil {
+		return APIListResponse[T]{}, fmt.Errorf("failed to create Request: %w", err)
 	}
 
-	// Set $top if exists
-	if opts.Top > 0 {
-		qp["$top"] = strconv.Itoa(opts.Top)
+	return getPage[T](q.client, req)
+}
+
+// Count makes a GET request to the "$count" of the query and returns the number
+// of records that match the BaseFilter and the Filter of the ListOptions.
+// The other options are ignored.
+func (q *APIQuery[T]) Count(ctx context.Context, opts ListOptions) (int, error) {
+	filterString := filter.And(filter.Raw(q.BaseFilter), filter.Raw(opts.Filter)).String()
+	return countRecords(ctx, q.client, q.entitySetName, filterString)
+}
+
+// requestOptions builds the RequestOptions for a GET request to the query.
+// The BaseSelect fields are selected before the Select of the ListOptions.
+func (q *APIQuery[T]) requestOptions(opts ListOptions) RequestOptions {
+	if len(q.BaseSelect) > 0 {
+		opts.Select = uniqueFields(slices.Concat(q.BaseSelect, opts.Select))
 	}
 
-	ropts := RequestOptions{
+	return RequestOptions{
 		Method:        http.MethodGet,
 		EntitySetName: q.entitySetName,
-		QueryParams:   qp,
-	}
-	req, err := q.client.NewRequest(ctx, ropts)
-	if err != nil {
-		return v, fmt.Errorf("failed to create Request: %w", err)
-	}
-
-	res, err := q.client.Do(req)
-	if err != nil {
-		return v, fmt.Errorf("failed during request: %w", err)
+		QueryParams:   opts.BuildQueryParams(q.BaseFilter, nil),
+		Header:        opts.BuildHeader(),
 	}
+}
 
-	list, err := Decode[APIListResponse[T]](res)
-	if err != nil {
-		var srvErr APIError
-		if errors.As(err, &srvErr) {
-			q.client.logger.Debug("API server returned error response.", "error", srvErr)
-			return v, fmt.Errorf("error from BC API: %w", srvErr)
+// uniqueFields removes the repeated fields and keeps the order.
+func uniqueFields(fields []string) []string {
+	seen := map[string]bool{}
+	unique := fields[:0]
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			unique = append(unique, f)
 		}
-
-		q.client.logger.Debug("Failed to decode response.", "error", err)
-		return v, fmt.Errorf("failed to decode response: %w", err)
 	}
-	v = list.Value
-	return v, nil
+	return unique
 }
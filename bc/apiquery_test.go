@@ -0,0 +1,99 @@
+package bc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/internal/bctest"
+)
+
+func TestAPIQueryListOptions(t *testing.T) {
+	var query url.Values
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		body := map[string]any{"value": []map[string]any{{"ID": "1"}}}
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := bc.NewAPIQuery[fakeEntity](client, "salesByCustomer")
+	q.BaseFilter = "documentType eq 'Invoice'"
+	q.BaseSelect = []string{"customerNumber", "amount"}
+
+	_, err = q.List(context.Background(), bc.ListOptions{
+		Filter:  "amount gt 0",
+		Select:  []string{"amount", "postingDate"},
+		OrderBy: []string{"customerNumber"},
+		Skip:    5,
+		Expand:  []string{"customer"},
+		Apply:   "groupby((customerNumber),aggregate(amount with sum as total))",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"$filter":  "(documentType eq 'Invoice') and (amount gt 0)",
+		"$select":  "customerNumber,amount,postingDate",
+		"$orderby": "customerNumber",
+		"$skip":    "5",
+		"$expand":  "customer",
+		"$apply":   "groupby((customerNumber),aggregate(amount with sum as total))",
+	}
+	for k, v := range want {
+		if got := query.Get(k); got != v {
+			t.Errorf("wrong %s: expected %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestAPIQueryListPageCount(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	for _, number := range []string{"1", "2", "3"} {
+		if _, err := srv.Insert("customerSales", fakeETagEntity{Number: number}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := bc.NewAPIQuery[fakeETagEntity](client, "customerSales")
+	q.BaseFilter = "number ne '3'"
+	ctx := context.Background()
+
+	page, err := q.ListPage(ctx, bc.ListOptions{Count: true, MaxPageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Value) != 1 || page.Count == nil || *page.Count != 2 {
+		t.Fatalf("wanted 1 record with count 2, got %d %v", len(page.Value), page.Count)
+	}
+
+	next, err := q.NextPage(ctx, page.NextLink, bc.ListOptions{MaxPageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Value) != 1 || next.Value[0].Number != "2" || next.NextLink != "" {
+		t.Errorf("wanted the last record 2, got %+v %q", next.Value, next.NextLink)
+	}
+
+	count, err := q.Count(ctx, bc.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("wanted 2, got %d", count)
+	}
+}
@@ -0,0 +1,233 @@
+package bc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxBatchOperations is the maximum number of operations BC accepts in one $batch request.
+const MaxBatchOperations = 100
+
+// Batch collects requests that are sent together in a single OData JSON $batch request.
+// Create one with [Client.NewBatch], add operations with Add and send them with Send.
+type Batch struct {
+	client   *Client
+	requests []batchRequest
+	ids      map[string]bool
+
+	// SnapshotIsolation sends the "Isolation: snapshot" header so all
+	// operations read from the same snapshot of the database.
+	SnapshotIsolation bool
+}
+
+// BatchOperation is a single request in a [Batch].
+type BatchOperation struct {
+	RequestOptions
+
+	// ID identifies the operation in the response. It is generated if empty.
+	ID string
+	// AtomicityGroup puts the operation in a change set. All operations in
+	// the same change set succeed or fail together.
+	AtomicityGroup string
+	// DependsOn lists the IDs of operations or atomicity groups that must
+	// succeed before this operation is run.
+	DependsOn []string
+}
+
+// batchRequest is an operation in the body of the $batch request.
+type batchRequest struct {
+	ID             string            `json:"id"`
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	AtomicityGroup string            `json:"atomicityGroup,omitempty"`
+	DependsOn      []string          `json:"dependsOn,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is the response body of a $batch request.
+type BatchResponse struct {
+	Responses []BatchOperationResponse `json:"responses"`
+}
+
+// Validate implements the Validator interface.
+func (r BatchResponse) Validate() error {
+	for i, res := range r.Responses {
+		if res.ID == "" {
+			return fmt.Errorf("response %d: missing id", i)
+		}
+	}
+	return nil
+}
+
+// Get returns the response for the operation ID.
+func (r BatchResponse) Get(id string) (BatchOperationResponse, bool) {
+	for _, res := range r.Responses {
+		if res.ID == id {
+			return res, true
+		}
+	}
+	return BatchOperationResponse{}, false
+}
+
+// BatchOperationResponse is the response to a single operation in a [Batch].
+type BatchOperationResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// Response converts the operation response into an http.Response so it can
+// be decoded with [Decode] or [DecodeNoContent].
+func (r BatchOperationResponse) Response() *http.Response {
+	header := http.Header{}
+	for k, v := range r.Headers {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		StatusCode: r.Status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(r.Body)),
+	}
+}
+
+// NewBatch creates an empty [Batch] for the Client.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{
+		client: c,
+		ids:    map[string]bool{},
+	}
+}
+
+// Len returns the number of operations in the batch.
+func (b *Batch) Len() int {
+	return len(b.requests)
+}
+
+// Add validates the operation and adds it to the batch. It returns the ID
+// of the operation, which is used to find its response. An empty ID is set to
+// the next number that is not taken. An ID that is already taken is an error.
+func (b *Batch) Add(op BatchOperation) (string, error) {
+	if err := op.Validate(); err != nil {
+		return "", err
+	}
+
+	// The body must be JSON
+	if _, ok := op.Body.(io.Reader); ok {
+		return "", errors.New("batch operation cannot have a stream body")
+	}
+
+	if len(b.requests) >= MaxBatchOperations {
+		return "", fmt.Errorf("batch is full: max %d operations", MaxBatchOperations)
+	}
+
+	// Number the operation, skipping the ids that were set explicitly
+	if op.ID == "" {
+		for n := len(b.requests) + 1; op.ID == "" || b.ids[op.ID]; n++ {
+			op.ID = strconv.Itoa(n)
+		}
+	}
+
+	if b.ids[op.ID] {
+		return "", fmt.Errorf("duplicate batch operation id %q", op.ID)
+	}
+
+	// The URL is relative to the API root, e.g. "companies({id})/salesOrders"
+	rootURL := b.client.apiRootURL()
+	reqURL := op.requestURL(*b.client.baseURL)
+	relURL := strings.TrimPrefix(reqURL.Path, rootURL.Path+"/")
+	if reqURL.RawQuery != "" {
+		relURL += "?" + reqURL.RawQuery
+	}
+
+	h := http.Header{}
+	h.Set("Accept", AcceptJSONMinimalMetadata)
+	setRequestHeaders(h, op.RequestOptions)
+
+	headers := map[string]string{}
+	for k := range h {
+		headers[k] = h.Get(k)
+	}
+
+	var body json.RawMessage
+	if op.Body != nil {
+		raw, err := json.Marshal(op.Body)
+		if err != nil {
+			return "", fmt.Errorf("cannot marshal body %s: %w", op.Body, err)
+		}
+		body = raw
+	}
+
+	b.ids[op.ID] = true
+	b.requests = append(b.requests, batchRequest{
+		ID:             op.ID,
+		Method:         op.Method,
+		URL:            relURL,
+		AtomicityGroup: op.AtomicityGroup,
+		DependsOn:      op.DependsOn,
+		Headers:        headers,
+		Body:           body,
+	})
+
+	return op.ID, nil
+}
+
+// Send sends all operations in a single $batch request. An error is only returned
+// if the batch itself failed. Check the status of each operation in the
+// [BatchResponse], or decode it with [Decode] using [BatchOperationResponse.Response].
+func (b *Batch) Send(ctx context.Context) (BatchResponse, error) {
+	var v BatchResponse
+
+	if len(b.requests) == 0 {
+		return v, errors.New("batch has no operations")
+	}
+
+	body, err := json.Marshal(map[string]any{"requests": b.requests})
+	if err != nil {
+		return v, fmt.Errorf("cannot marshal batch: %w", err)
+	}
+
+	batchURL := b.client.apiRootURL()
+	batchURL.Path += "/$batch"
+
+	req, err := b.client.newRequestURL(ctx, http.MethodPost, batchURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return v, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", ContentTypeJSON)
+	req.Header.Set("Accept", ContentTypeJSON)
+	if b.SnapshotIsolation {
+		req.Header.Set("Isolation", "snapshot")
+	}
+
+	b.client.logger.Debug("Sending batch request...", "url", req.URL.String(), "operations", len(b.requests))
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return v, fmt.Errorf("failed during request: %w", err)
+	}
+
+	v, err = Decode[BatchResponse](res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			b.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return v, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		b.client.logger.Debug("Failed to decode response.", "error", err)
+		return v, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,169 @@
+package bc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+	"github.com/google/uuid"
+)
+
+func TestBatchSend(t *testing.T) {
+	var got struct {
+		Requests []struct {
+			ID             string            `json:"id"`
+			Method         string            `json:"method"`
+			URL            string            `json:"url"`
+			AtomicityGroup string            `json:"atomicityGroup"`
+			DependsOn      []string          `json:"dependsOn"`
+			Headers        map[string]string `json:"headers"`
+			Body           map[string]any    `json:"body"`
+		} `json:"requests"`
+	}
+	var batchReq *http.Request
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		batchReq = r
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		body := map[string]any{
+			"responses": []map[string]any{
+				{"id": "header", "status": 201, "body": map[string]any{"ID": "1"}},
+				{"id": "2", "status": 204},
+			},
+		}
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := client.NewBatch()
+	batch.SnapshotIsolation = true
+
+	headerID, err := batch.Add(bc.BatchOperation{
+		ID:             "header",
+		AtomicityGroup: "g1",
+		RequestOptions: bc.RequestOptions{
+			Method:        http.MethodPost,
+			EntitySetName: "fakeEntities",
+			Body:          map[string]any{"number": "1000"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleteID, err := batch.Add(bc.BatchOperation{
+		AtomicityGroup: "g1",
+		DependsOn:      []string{headerID},
+		RequestOptions: bc.RequestOptions{
+			Method:        http.MethodDelete,
+			EntitySetName: "fakeEntities",
+			RecordID:      uuid.New(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := batch.Add(bc.BatchOperation{ID: "header", RequestOptions: bc.RequestOptions{Method: http.MethodGet, EntitySetName: "fakeEntities"}}); err == nil {
+		t.Error("expected error for duplicate id, got nil")
+	}
+
+	res, err := batch.Send(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Request", func(t *testing.T) {
+		if !strings.HasSuffix(batchReq.URL.Path, "/api/publisher/group/1.0/$batch") {
+			t.Errorf("wrong batch path: %s", batchReq.URL.Path)
+		}
+		if batchReq.Header.Get("Isolation") != "snapshot" {
+			t.Errorf("wanted Isolation snapshot, got %q", batchReq.Header.Get("Isolation"))
+		}
+		if len(got.Requests) != 2 {
+			t.Fatalf("wanted 2 requests, got %d", len(got.Requests))
+		}
+
+		post := got.Requests[0]
+		if !strings.HasPrefix(post.URL, "companies(") || !strings.HasSuffix(post.URL, ")/fakeEntities") {
+			t.Errorf("wrong relative url: %s", post.URL)
+		}
+		if post.AtomicityGroup != "g1" || post.Body["number"] != "1000" {
+			t.Errorf("wrong post request: %+v", post)
+		}
+		if post.Headers["Content-Type"] != bc.ContentTypeJSON {
+			t.Errorf("wrong Content-Type: %q", post.Headers["Content-Type"])
+		}
+
+		del := got.Requests[1]
+		if del.ID != deleteID || len(del.DependsOn) != 1 || del.DependsOn[0] != "header" {
+			t.Errorf("wrong delete request: %+v", del)
+		}
+		if del.Headers["If-Match"] != "*" {
+			t.Errorf("wrong If-Match: %q", del.Headers["If-Match"])
+		}
+	})
+
+	t.Run("Response", func(t *testing.T) {
+		op, ok := res.Get(headerID)
+		if !ok {
+			t.Fatal("missing response for header")
+		}
+		record, err := bc.Decode[fakeEntity](op.Response())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.ID != "1" {
+			t.Errorf("wanted ID 1, got %s", record.ID)
+		}
+
+		op, ok = res.Get(deleteID)
+		if !ok {
+			t.Fatal("missing response for delete")
+		}
+		if err := bc.DecodeNoContent(op.Response()); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestBatchAutoID(t *testing.T) {
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := bc.RequestOptions{Method: http.MethodGet, EntitySetName: "fakeEntities"}
+	batch := client.NewBatch()
+
+	var ids []string
+	for _, id := range []string{"2", "", ""} {
+		got, err := batch.Add(bc.BatchOperation{ID: id, RequestOptions: get})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, got)
+	}
+
+	// The second operation would be "2", which is taken
+	if want := []string{"2", "3", "4"}; !slices.Equal(ids, want) {
+		t.Errorf("wanted ids %v, got %v", want, ids)
+	}
+
+	// An explicit id that was generated is still a duplicate
+	if _, err := batch.Add(bc.BatchOperation{ID: "3", RequestOptions: get}); err == nil {
+		t.Error("expected error for duplicate id, got nil")
+	}
+}
@@ -0,0 +1,417 @@
+package bcfake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// expr is a parsed $filter expression.
+type expr interface {
+	match(record map[string]any) bool
+}
+
+// operand is a field or a literal in a $filter expression.
+type operand interface {
+	value(record map[string]any) any
+}
+
+type field []string
+
+func (f field) value(record map[string]any) any {
+	var v any = record
+	for _, name := range f {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+type literal struct{ v any }
+
+func (l literal) value(map[string]any) any { return l.v }
+
+type comparison struct {
+	op          string
+	left, right operand
+}
+
+func (c comparison) match(record map[string]any) bool {
+	return compareValues(c.left.value(record), c.right.value(record), c.op)
+}
+
+type call struct {
+	name        string
+	left, right operand
+}
+
+func (c call) match(record map[string]any) bool {
+	s, ok := c.left.value(record).(string)
+	sub, ok2 := c.right.value(record).(string)
+	if !ok || !ok2 {
+		return false
+	}
+
+	switch c.name {
+	case "startswith":
+		return strings.HasPrefix(s, sub)
+	case "endswith":
+		return strings.HasSuffix(s, sub)
+	default:
+		return strings.Contains(s, sub)
+	}
+}
+
+type logical struct {
+	and         bool
+	left, right expr
+}
+
+func (l logical) match(record map[string]any) bool {
+	if l.and {
+		return l.left.match(record) && l.right.match(record)
+	}
+	return l.left.match(record) || l.right.match(record)
+}
+
+type not struct{ e expr }
+
+func (n not) match(record map[string]any) bool { return !n.e.match(record) }
+
+// matchAll is used when there is no $filter.
+type matchAll struct{}
+
+func (matchAll) match(map[string]any) bool { return true }
+
+// compareValues compares two JSON values. Strings that are both timestamps
+// are compared as times, so different offsets of the same moment are equal.
+func compareValues(a, b any, op string) bool {
+	if a == nil || b == nil {
+		switch op {
+		case "eq":
+			return a == nil && b == nil
+		case "ne":
+			return (a == nil) != (b == nil)
+		}
+		return false
+	}
+
+	var cmp int
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareOrdered(av, bv)
+	case bool:
+		bv, ok := b.(bool)
+		if !ok || (op != "eq" && op != "ne") {
+			return false
+		}
+		cmp = 1
+		if av == bv {
+			cmp = 0
+		}
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return false
+		}
+		at, aErr := time.Parse(time.RFC3339Nano, av)
+		bt, bErr := time.Parse(time.RFC3339Nano, bv)
+		switch {
+		case aErr == nil && bErr == nil:
+			cmp = at.Compare(bt)
+		case isGUID(av) && isGUID(bv):
+			cmp = strings.Compare(strings.ToLower(av), strings.ToLower(bv))
+		default:
+			cmp = strings.Compare(av, bv)
+		}
+	default:
+		return false
+	}
+
+	switch op {
+	case "eq":
+		return cmp == 0
+	case "ne":
+		return cmp != 0
+	case "gt":
+		return cmp > 0
+	case "ge":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "le":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isGUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil && len(s) == 36
+}
+
+// parseFilter parses the subset of $filter supported by the Server.
+func parseFilter(s string) (expr, error) {
+	if strings.TrimSpace(s) == "" {
+		return matchAll{}, nil
+	}
+
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return e, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits the filter into quoted strings, parentheses, commas and words.
+// Words are fields, operators and unquoted literals like numbers, GUIDs and dates.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	r := []rune(s)
+
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '\'':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(r) {
+					return nil, fmt.Errorf("unterminated string in %q", s)
+				}
+				if r[i] == '\'' {
+					// Single quotes are escaped by doubling them
+					if i+1 < len(r) && r[i+1] == '\'' {
+						b.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteRune(r[i])
+				i++
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+		default:
+			start := i
+			for i < len(r) && !unicode.IsSpace(r[i]) && !strings.ContainsRune("(),'", r[i]) {
+				i++
+			}
+			tokens = append(tokens, token{text: string(r[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) expect(text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.quoted || t.text != text {
+		return fmt.Errorf("expected %q, got %q", text, t.text)
+	}
+	return nil
+}
+
+// isKeyword returns true if the next token is the unquoted keyword.
+func (p *parser) isKeyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	if p.isKeyword("(") {
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	}
+
+	for _, name := range []string{"startswith", "endswith", "contains"} {
+		if p.isKeyword(name) {
+			p.pos++
+			return p.parseCall(name)
+		}
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(t.text)
+	switch op {
+	case "eq", "ne", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", t.text)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseCall(name string) (expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return call{name: name, left: left, right: right}, p.expect(")")
+}
+
+// parseOperand parses a quoted string, an unquoted literal or a field path.
+func (p *parser) parseOperand() (operand, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.quoted {
+		return literal{t.text}, nil
+	}
+
+	switch strings.ToLower(t.text) {
+	case "(", ")", ",":
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	case "null":
+		return literal{nil}, nil
+	case "true":
+		return literal{true}, nil
+	case "false":
+		return literal{false}, nil
+	}
+
+	if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return literal{f}, nil
+	}
+
+	// GUIDs, dates and times are unquoted
+	if unicode.IsDigit(rune(t.text[0])) || isGUID(t.text) {
+		return literal{t.text}, nil
+	}
+
+	return field(strings.Split(t.text, "/")), nil
+}
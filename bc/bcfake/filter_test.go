@@ -0,0 +1,61 @@
+package bcfake
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	record := map[string]any{
+		"number":  "10000",
+		"name":    "O'Brien",
+		"amount":  float64(250),
+		"blocked": false,
+		"id":      "5d115c9c-44e3-ea11-bb43-000d3a2feca1",
+		"date":    "2024-03-01",
+		"updated": "2024-03-01T10:00:00Z",
+		"address": map[string]any{"city": "Seattle"},
+		"missing": nil,
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"number eq '10000'", true},
+		{"name eq 'O''Brien'", true},
+		{"amount gt 100 and amount le 250", true},
+		{"amount lt 100 or blocked eq false", true},
+		{"not (blocked eq false)", false},
+		{"id eq 5D115C9C-44E3-EA11-BB43-000D3A2FECA1", true},
+		{"date ge 2024-01-01", true},
+		{"updated gt 2024-03-01T09:00:00-02:00", false},
+		{"address/city eq 'Seattle'", true},
+		{"missing eq null", true},
+		{"startswith(name,'O''B') and contains(number,'00')", true},
+		{"endswith(name,'x')", false},
+		{"(amount eq 1 or amount eq 250) and number ne '20000'", true},
+	}
+
+	for _, tt := range tests {
+		e, err := parseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("%s: %s", tt.filter, err)
+			continue
+		}
+		if got := e.match(record); got != tt.want {
+			t.Errorf("%s: wanted %t, got %t", tt.filter, tt.want, got)
+		}
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	for _, f := range []string{
+		"number eq",
+		"number is '1'",
+		"(number eq '1'",
+		"name eq 'unterminated",
+		"number eq '1' extra",
+	} {
+		if _, err := parseFilter(f); err == nil {
+			t.Errorf("%s: expected error, got nil", f)
+		}
+	}
+}
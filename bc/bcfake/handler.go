@@ -0,0 +1,482 @@
+package bcfake
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/google/uuid"
+)
+
+// target is the resource addressed by the path of a request.
+type target struct {
+	set *entitySet
+	id  uuid.UUID
+	// parent is set for the records of a navigation property,
+	// e.g. "salesOrders({id})/salesOrderLines".
+	parent *scope
+	// action is the name of a bound action, e.g. "Microsoft.NAV.post".
+	action string
+	// media is the path of a media stream, e.g. "picture/pictureContent".
+	media string
+	// count is set for the "$count" of the records.
+	count bool
+}
+
+// scope limits the records to the children of a parent record.
+type scope struct {
+	foreignKey string
+	parentID   uuid.UUID
+}
+
+// contains returns true if the record is in the scope.
+func (sc *scope) contains(r *record) bool {
+	return sc == nil || compareValues(r.data[sc.foreignKey], sc.parentID.String(), "eq")
+}
+
+// serveHTTP routes the request to the entity set.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w = &responseWriter{
+		ResponseWriter: w,
+		noMetadata:     strings.Contains(r.Header.Get("Accept"), bc.NoODATAMetadata),
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, &Error{http.StatusUnauthorized, "Unauthorized", "The credentials provided are incorrect."})
+		return
+	}
+
+	if id, ok := subscriptionPath(r.URL.Path); ok {
+		s.serveSubscriptions(w, r, id)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/companies") && r.Method == http.MethodGet {
+		s.listCompanies(w)
+		return
+	}
+
+	companyID, segments, ok := resourcePath(r.URL.Path)
+	if !ok || len(segments) == 0 {
+		writeError(w, notFound("The request URI %q is not valid.", r.URL.Path))
+		return
+	}
+
+	c, apiErr := s.company(companyID)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	t, apiErr := s.resolve(c, segments)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	switch {
+	case t.count && r.Method == http.MethodGet:
+		s.count(w, r, t)
+	case t.count:
+		writeError(w, &Error{http.StatusMethodNotAllowed, "BadRequest_MethodNotAllowed",
+			"The requested method is not allowed for the resource."})
+	case t.media != "" && r.Method == http.MethodGet:
+		s.downloadMedia(w, t)
+	case t.media != "" && r.Method == http.MethodPatch:
+		s.uploadMedia(w, r, t)
+	case t.media != "":
+		writeError(w, &Error{http.StatusMethodNotAllowed, "BadRequest_MethodNotAllowed",
+			"The requested method is not allowed for the resource."})
+	case t.action != "" && r.Method == http.MethodPost:
+		s.action(w, r, t)
+	case t.action != "":
+		writeError(w, notFound("The request URI %q is not valid.", r.URL.Path))
+	case r.Method == http.MethodGet && t.id == uuid.Nil:
+		s.list(w, r, t)
+	case r.Method == http.MethodGet:
+		s.get(w, r, t)
+	case r.Method == http.MethodPost && t.id == uuid.Nil:
+		s.create(w, r, t)
+	case r.Method == http.MethodPatch && t.id != uuid.Nil:
+		s.update(w, r, t)
+	case r.Method == http.MethodDelete && t.id != uuid.Nil:
+		s.delete(w, r, t)
+	default:
+		writeError(w, &Error{http.StatusMethodNotAllowed, "BadRequest_MethodNotAllowed",
+			"The requested method is not allowed for the resource."})
+	}
+}
+
+// resolve walks the path segments. Each segment after the first is a
+// collection navigation of the previous record, or a bound action if it is
+// the last. Must be called with the lock held.
+func (s *Server) resolve(c *company, segments []string) (target, *Error) {
+	count := len(segments) > 1 && segments[len(segments)-1] == "$count"
+	if count {
+		segments = segments[:len(segments)-1]
+	}
+
+	name, id, apiErr := parseSegment(segments[0])
+	if apiErr != nil {
+		return target{}, apiErr
+	}
+
+	if !s.entitySets[name] {
+		return target{}, notFound("No HTTP resource was found that matches the entity set %q.", name)
+	}
+	t := target{set: c.set(name), id: id}
+
+	for i, seg := range segments[1:] {
+		if t.id == uuid.Nil {
+			return target{}, notFound("The segment %q must follow a record id.", seg)
+		}
+
+		// The record must exist, and be in the scope of its parent
+		if _, apiErr := s.record(t); apiErr != nil {
+			return target{}, apiErr
+		}
+
+		if strings.Contains(seg, ".") && i == len(segments)-2 {
+			t.action = seg
+			return t, nil
+		}
+
+		if path := strings.Join(segments[i+1:], "/"); s.mediaPaths[t.set.name][path] {
+			t.media = path
+			return t, nil
+		}
+
+		name, id, apiErr := parseSegment(seg)
+		if apiErr != nil {
+			return target{}, apiErr
+		}
+
+		nav, ok := s.navigations[t.set.name][name]
+		if !ok || !nav.Collection {
+			return target{}, notFound("Could not find a navigation property named '%s' on type '%s'.", name, t.set.name)
+		}
+
+		t = target{
+			set:    c.set(nav.EntitySet),
+			id:     id,
+			parent: &scope{foreignKey: nav.ForeignKey, parentID: t.id},
+		}
+	}
+
+	if count {
+		if t.id != uuid.Nil {
+			return target{}, notFound("The segment %q must follow an entity set.", "$count")
+		}
+		t.count = true
+	}
+
+	return t, nil
+}
+
+// record returns the record of the target. Must be called with the lock held.
+func (s *Server) record(t target) (*record, *Error) {
+	r, apiErr := t.set.get(t.id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !t.parent.contains(r) {
+		return nil, notFound("The %s does not exist. Identification fields and values: Id='%s'", t.set.name, t.id)
+	}
+	return r, nil
+}
+
+func (s *Server) listCompanies(w http.ResponseWriter) {
+	values := make([]map[string]any, len(s.companies))
+	for i, c := range s.companies {
+		values[i] = c.object()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"value": values})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, t target) {
+	q, apiErr := parseQuery(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	page, nextOffset, apiErr := s.query(t.set, t.parent, q)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	body := map[string]any{"value": page}
+	if q.count {
+		body["@odata.count"] = s.matchCount(t.set, t.parent, q.filter)
+	}
+	if nextOffset > 0 {
+		body["@odata.nextLink"] = s.nextLink(r, nextOffset)
+	}
+	if q.maxPageSize > 0 {
+		w.Header().Set("Preference-Applied", r.Header.Get("Prefer"))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// count writes the number of records that match the $filter as text.
+func (s *Server) count(w http.ResponseWriter, r *http.Request, t target) {
+	q, apiErr := parseQuery(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, s.matchCount(t.set, t.parent, q.filter))
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, t target) {
+	q, apiErr := parseQuery(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	rec, apiErr := s.record(t)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	s.writeRecord(w, http.StatusOK, t.set, rec, q)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, t target) {
+	q, apiErr := parseQuery(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, badRequest("Invalid request body: %s", err))
+		return
+	}
+	if data == nil {
+		writeError(w, badRequest("Invalid request body: expected a JSON object"))
+		return
+	}
+
+	// Link the record to the parent
+	if t.parent != nil {
+		data[t.parent.foreignKey] = t.parent.parentID.String()
+	}
+
+	rec, apiErr := s.insertDeep(t.set, data)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	s.writeRecord(w, http.StatusCreated, t.set, rec, q)
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, t target) {
+	q, apiErr := parseQuery(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	var fields map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		writeError(w, badRequest("Invalid request body: %s", err))
+		return
+	}
+
+	if _, apiErr := s.record(t); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	rec, apiErr := t.set.update(t.id, r.Header.Get("If-Match"), fields)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	s.writeRecord(w, http.StatusOK, t.set, rec, q)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, t target) {
+	if _, apiErr := s.record(t); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	if apiErr := t.set.delete(t.id, r.Header.Get("If-Match")); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) action(w http.ResponseWriter, r *http.Request, t target) {
+	fn, ok := s.actions[t.set.name][t.action]
+	if !ok {
+		writeError(w, &Error{http.StatusNotFound, "BadRequest_MethodNotFound",
+			fmt.Sprintf("Could not find an operation named '%s' on type '%s'.", t.action, t.set.name)})
+		return
+	}
+
+	rec, apiErr := s.record(t)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, badRequest("Invalid request body: %s", err))
+		return
+	}
+
+	result, err := fn(rec.data, body)
+	if err != nil {
+		var actionErr *Error
+		if !errors.As(err, &actionErr) {
+			actionErr = &Error{http.StatusInternalServerError, "Internal_ServerError", err.Error()}
+		}
+		writeError(w, actionErr)
+		return
+	}
+
+	// The action may have changed the record
+	rec.version++
+	rec.data["lastModifiedDateTime"] = now()
+
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// insertDeep inserts the record and the records of its collection navigations,
+// e.g. a sales order with an inline "salesOrderLines" array. Nothing is
+// inserted if any of them fails. Must be called with the lock held.
+func (s *Server) insertDeep(set *entitySet, data map[string]any) (*record, *Error) {
+	// Inserts only append, so restoring the slices rolls them back
+	snapshot := map[*entitySet][]*record{}
+	for _, es := range set.company.sets {
+		snapshot[es] = es.records
+	}
+
+	rec, apiErr := s.insertTree(set, data)
+	if apiErr != nil {
+		// Entity sets added during the insert are not in the snapshot and become empty
+		for _, es := range set.company.sets {
+			es.records = snapshot[es]
+		}
+		return nil, apiErr
+	}
+	return rec, nil
+}
+
+// insertTree inserts the record and then its children.
+func (s *Server) insertTree(set *entitySet, data map[string]any) (*record, *Error) {
+	children := map[string][]any{}
+	for name, nav := range s.navigations[set.name] {
+		v, ok := data[name]
+		if !ok || !nav.Collection {
+			continue
+		}
+		values, ok := v.([]any)
+		if !ok {
+			return nil, badRequest("The navigation property '%s' must be an array.", name)
+		}
+		children[name] = values
+		delete(data, name)
+	}
+
+	rec, apiErr := set.insert(data)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for name, values := range children {
+		nav := s.navigations[set.name][name]
+		for _, v := range values {
+			child, ok := v.(map[string]any)
+			if !ok {
+				return nil, badRequest("The records of '%s' must be objects.", name)
+			}
+			child[nav.ForeignKey] = rec.id.String()
+			if _, apiErr := s.insertTree(set.company.set(nav.EntitySet), child); apiErr != nil {
+				return nil, apiErr
+			}
+		}
+	}
+
+	return rec, nil
+}
+
+func (s *Server) downloadMedia(w http.ResponseWriter, t target) {
+	rec, apiErr := s.record(t)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	m := rec.media[t.media]
+	if len(m.data) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", cmp.Or(m.contentType, "application/octet-stream"))
+	w.Header().Set("Content-Length", strconv.Itoa(len(m.data)))
+	w.Write(m.data)
+}
+
+func (s *Server) uploadMedia(w http.ResponseWriter, r *http.Request, t target) {
+	rec, apiErr := s.record(t)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if apiErr := checkIfMatch(rec, r.Header.Get("If-Match")); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, badRequest("Invalid request body: %s", err))
+		return
+	}
+
+	rec.setMedia(t.media, r.Header.Get("Content-Type"), data)
+	rec.version++
+	rec.data["lastModifiedDateTime"] = now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRecord writes the record with the $expand and $select applied.
+func (s *Server) writeRecord(w http.ResponseWriter, status int, set *entitySet, rec *record, q query) {
+	obj, apiErr := s.shape(set, rec, q)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	w.Header().Set("ETag", rec.etag())
+	writeJSON(w, status, obj)
+}
@@ -0,0 +1,255 @@
+package bcfake
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// query holds the parsed query options and Prefer header of a request.
+type query struct {
+	filter      expr
+	orderBy     []orderTerm
+	top         int // -1 when not set
+	skip        int
+	selects     []string
+	expand      []string
+	maxPageSize int
+	skipToken   int
+	count       bool
+}
+
+type orderTerm struct {
+	field field
+	desc  bool
+}
+
+var maxPageSizeRe = regexp.MustCompile(`odata\.maxpagesize=(\d+)`)
+
+// parseQuery parses the query options of the request.
+func parseQuery(r *http.Request) (query, *Error) {
+	values := r.URL.Query()
+	q := query{top: -1}
+
+	var err error
+	q.filter, err = parseFilter(values.Get("$filter"))
+	if err != nil {
+		return q, badRequest("Invalid $filter: %s", err)
+	}
+
+	for _, term := range splitList(values.Get("$orderby")) {
+		fields := strings.Fields(term)
+		q.orderBy = append(q.orderBy, orderTerm{
+			field: field(strings.Split(fields[0], "/")),
+			desc:  len(fields) > 1 && strings.EqualFold(fields[1], "desc"),
+		})
+	}
+
+	for _, opt := range []struct {
+		name string
+		dest *int
+	}{
+		{"$top", &q.top},
+		{"$skip", &q.skip},
+		{"$skiptoken", &q.skipToken},
+	} {
+		v := values.Get(opt.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, badRequest("Invalid %s %q.", opt.name, v)
+		}
+		*opt.dest = n
+	}
+
+	if v := values.Get("$count"); v != "" {
+		count, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, badRequest("Invalid $count %q.", v)
+		}
+		q.count = count
+	}
+
+	q.selects = splitList(values.Get("$select"))
+
+	// Nested options like "lines($select=id)" are not supported and ignored
+	for _, e := range splitList(values.Get("$expand")) {
+		name, _, _ := strings.Cut(e, "(")
+		q.expand = append(q.expand, name)
+	}
+
+	if m := maxPageSizeRe.FindStringSubmatch(r.Header.Get("Prefer")); m != nil {
+		q.maxPageSize, _ = strconv.Atoi(m[1])
+	}
+
+	return q, nil
+}
+
+// splitList splits a comma separated query option, ignoring the
+// commas inside parentheses.
+func splitList(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[start:])
+
+	var trimmed []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			trimmed = append(trimmed, p)
+		}
+	}
+	return trimmed
+}
+
+// query returns the page of shaped records in the scope and the offset of the
+// next page, or zero if it is the last page. Must be called with the lock held.
+func (s *Server) query(set *entitySet, sc *scope, q query) ([]map[string]any, int, *Error) {
+	var matched []*record
+	for _, r := range set.records {
+		if sc.contains(r) && q.filter.match(r.data) {
+			matched = append(matched, r)
+		}
+	}
+
+	if len(q.orderBy) > 0 {
+		slices.SortStableFunc(matched, func(a, b *record) int {
+			for _, term := range q.orderBy {
+				c := compareOrder(term.field.value(a.data), term.field.value(b.data))
+				if term.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	matched = matched[min(q.skip, len(matched)):]
+	if q.top >= 0 {
+		matched = matched[:min(q.top, len(matched))]
+	}
+
+	// Page with the skip token from the next link
+	start := min(q.skipToken, len(matched))
+	end := len(matched)
+	next := 0
+	if q.maxPageSize > 0 && start+q.maxPageSize < end {
+		end = start + q.maxPageSize
+		next = end
+	}
+
+	page := make([]map[string]any, 0, end-start)
+	for _, r := range matched[start:end] {
+		obj, apiErr := s.shape(set, r, q)
+		if apiErr != nil {
+			return nil, 0, apiErr
+		}
+		page = append(page, obj)
+	}
+	return page, next, nil
+}
+
+// matchCount returns the number of records in the scope that match the filter,
+// ignoring $top and $skip. Must be called with the lock held.
+func (s *Server) matchCount(set *entitySet, sc *scope, filter expr) int {
+	n := 0
+	for _, r := range set.records {
+		if sc.contains(r) && filter.match(r.data) {
+			n++
+		}
+	}
+	return n
+}
+
+// compareOrder orders null first and then by value.
+func compareOrder(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case compareValues(a, b, "lt"):
+		return -1
+	case compareValues(a, b, "gt"):
+		return 1
+	}
+	return 0
+}
+
+// shape returns the record object with the $expand and $select applied.
+// Must be called with the lock held.
+func (s *Server) shape(set *entitySet, r *record, q query) (map[string]any, *Error) {
+	obj := r.object()
+
+	if len(q.selects) > 0 {
+		selected := map[string]any{"@odata.etag": obj["@odata.etag"]}
+		for _, name := range q.selects {
+			if v, ok := obj[name]; ok {
+				selected[name] = v
+			}
+		}
+		obj = selected
+	}
+
+	for _, name := range q.expand {
+		nav, ok := s.navigations[set.name][name]
+		if !ok {
+			return nil, badRequest("Could not find a property named '%s' on type '%s'.", name, set.name)
+		}
+		obj[name] = s.expand(set, r, nav)
+	}
+
+	return obj, nil
+}
+
+// expand returns the related records of the navigation property.
+func (s *Server) expand(set *entitySet, r *record, nav Navigation) any {
+	related := set.company.set(nav.EntitySet)
+
+	if nav.Collection {
+		values := []map[string]any{}
+		for _, child := range related.records {
+			if compareValues(child.data[nav.ForeignKey], r.id.String(), "eq") {
+				values = append(values, child.object())
+			}
+		}
+		return values
+	}
+
+	for _, other := range related.records {
+		if compareValues(r.data[nav.ForeignKey], other.id.String(), "eq") {
+			return other.object()
+		}
+	}
+	return nil
+}
+
+// nextLink returns the URL of the request with the skip token of the next page.
+func (s *Server) nextLink(r *http.Request, offset int) string {
+	u := *r.URL
+	values := u.Query()
+	values.Set("$skiptoken", strconv.Itoa(offset))
+	u.RawQuery = values.Encode()
+	return s.URL + u.RequestURI()
+}
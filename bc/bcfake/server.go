@@ -0,0 +1,409 @@
+// Package bcfake provides an in-process fake of the Business Central API
+// for tests. It stores records in memory and serves them with the same URL
+// layout as the bc.Client, so the real client can be used against it.
+//
+//	srv := bcfake.NewServer()
+//	defer srv.Close()
+//
+//	srv.Insert("customers", map[string]any{"number": "10000", "displayName": "Adatum"})
+//
+//	client, err := srv.Client()
+//	customers := bc.NewAPIPage[Customer](client, "customers")
+//
+// It supports GET, list, POST, PATCH and DELETE, the If-Match header, the
+// "Prefer: odata.maxpagesize" header, the query options $filter, $top,
+// $skip, $orderby, $select, $expand and $count, and the "/$count" path. The $filter supports the comparison
+// operators, and, or, not, parentheses and the startswith, endswith and
+// contains functions. Errors are returned as a bc.ErrorResponse.
+//
+// Collection navigations added with AddNavigation can also be used in the
+// path, e.g. "salesOrders({id})/salesOrderLines", to list, create and change
+// the records of a parent, and a POST with an inline array of them is a deep
+// insert. Bound actions are added with AddAction and media streams with AddMedia.
+//
+// Webhook subscriptions can be created, renewed and deleted. Like BC, the
+// notification URL must respond to the validation request with the token.
+// Notifications are not sent automatically, call Notify after a change.
+package bcfake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/google/uuid"
+)
+
+// Server is a fake BC API server. Every company has its own records, while
+// the entity sets, navigations, actions and media are shared. All tenants
+// and endpoints are the same.
+type Server struct {
+	*httptest.Server
+
+	// TenantID, ClientID, Environment and CompanyID are used by ClientConfig.
+	TenantID    string
+	ClientID    string
+	Environment string
+	CompanyID   string
+
+	mu          sync.Mutex
+	companies   []*company
+	entitySets  map[string]bool
+	navigations map[string]map[string]Navigation
+	actions     map[string]map[string]ActionFunc
+	mediaPaths  map[string]map[string]bool
+
+	subscriptions []*record
+}
+
+// Navigation describes a navigation property that can be expanded with $expand.
+type Navigation struct {
+	// EntitySet holds the related records.
+	EntitySet string
+	// ForeignKey links the records. For a collection it is the field of the
+	// related records that holds the id of the record, e.g. "documentId".
+	// Otherwise it is the field of the record that holds the id of the
+	// related record, e.g. "currencyId".
+	ForeignKey string
+	// Collection is true if there are many related records.
+	Collection bool
+}
+
+// NewServer starts a Server. Call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		TenantID:    uuid.NewString(),
+		ClientID:    uuid.NewString(),
+		Environment: "test",
+		CompanyID:   uuid.NewString(),
+		entitySets:  map[string]bool{},
+		navigations: map[string]map[string]Navigation{},
+		actions:     map[string]map[string]ActionFunc{},
+		mediaPaths:  map[string]map[string]bool{},
+	}
+	s.companies = []*company{newCompany(uuid.MustParse(s.CompanyID), "CRONUS USA, Inc.")}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ClientConfig returns a ClientConfig for the "v2.0" endpoint of the Server.
+// It has no credential so use it with bc.WithAuthClient and a [TokenGetter].
+func (s *Server) ClientConfig() bc.ClientConfig {
+	return bc.ClientConfig{
+		TenantID:    s.TenantID,
+		ClientID:    s.ClientID,
+		Environment: s.Environment,
+		CompanyID:   s.CompanyID,
+		APIEndpoint: "v2.0",
+		APIHost:     s.URL,
+	}
+}
+
+// Client creates a bc.Client for the Server with a [TokenGetter].
+// The options are applied after the defaults.
+func (s *Server) Client(opts ...bc.ClientOption) (*bc.Client, error) {
+	defaults := []bc.ClientOption{
+		bc.WithAuthClient(TokenGetter{}),
+		bc.WithHTTPClient(s.Server.Client()),
+	}
+	return bc.NewClient(s.ClientConfig(), append(defaults, opts...)...)
+}
+
+// AddEntitySet adds an empty entity set. Requests to entity sets that
+// were not added or inserted into return a not found error.
+func (s *Server) AddEntitySet(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entitySet(name)
+}
+
+// AddNavigation adds a navigation property to the records of the entity set.
+func (s *Server) AddNavigation(entitySetName, name string, nav Navigation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entitySet(entitySetName)
+	s.entitySet(nav.EntitySet)
+
+	if s.navigations[entitySetName] == nil {
+		s.navigations[entitySetName] = map[string]Navigation{}
+	}
+	s.navigations[entitySetName][name] = nav
+}
+
+// ActionFunc handles a bound action. It gets the record, which it may change,
+// and the request body, which is nil if there is none. A nil result responds
+// with 204 No Content. Return an [*Error] to respond with a BC error.
+type ActionFunc func(record map[string]any, body map[string]any) (any, error)
+
+// AddAction adds a bound action to the records of the entity set. The name
+// is prefixed with "Microsoft.NAV." unless it is already qualified.
+func (s *Server) AddAction(entitySetName, name string, fn ActionFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entitySet(entitySetName)
+
+	if !strings.Contains(name, ".") {
+		name = "Microsoft.NAV." + name
+	}
+	if s.actions[entitySetName] == nil {
+		s.actions[entitySetName] = map[string]ActionFunc{}
+	}
+	s.actions[entitySetName][name] = fn
+}
+
+// AddMedia adds a media stream to the records of the entity set, e.g.
+// "picture/pictureContent". It can be downloaded with GET and replaced with PATCH.
+func (s *Server) AddMedia(entitySetName, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addMedia(entitySetName, path)
+}
+
+func (s *Server) addMedia(entitySetName, path string) {
+	s.entitySet(entitySetName)
+	if s.mediaPaths[entitySetName] == nil {
+		s.mediaPaths[entitySetName] = map[string]bool{}
+	}
+	s.mediaPaths[entitySetName][path] = true
+}
+
+// SetMedia sets the content of the media stream of a record, adding the media if needed.
+func (s *Server) SetMedia(entitySetName string, id uuid.UUID, path, contentType string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addMedia(entitySetName, path)
+
+	r, apiErr := s.entitySet(entitySetName).get(id)
+	if apiErr != nil {
+		return apiErr
+	}
+	r.setMedia(path, contentType, data)
+	return nil
+}
+
+// Media returns the content and Content-Type of the media stream of a record.
+func (s *Server) Media(entitySetName string, id uuid.UUID, path string) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.entitySets[entitySetName] {
+		return nil, "", notFound("No HTTP resource was found that matches the entity set %q.", entitySetName)
+	}
+	r, apiErr := s.entitySet(entitySetName).get(id)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	m := r.media[path]
+	return m.data, m.contentType, nil
+}
+
+// Insert adds a record to the entity set of the default company, adding the
+// entity set if needed. The record is marshaled to a JSON object. If it has no
+// "id" a new one is assigned. It returns the id of the record.
+func (s *Server) Insert(entitySetName string, record any) (uuid.UUID, error) {
+	return s.InsertInto(uuid.MustParse(s.CompanyID), entitySetName, record)
+}
+
+// InsertInto adds a record to the entity set of the company like Insert.
+func (s *Server) InsertInto(companyID uuid.UUID, entitySetName string, record any) (uuid.UUID, error) {
+	data, err := toObject(record)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, apiErr := s.company(companyID.String())
+	if apiErr != nil {
+		return uuid.Nil, apiErr
+	}
+
+	s.entitySets[entitySetName] = true
+	r, apiErr := c.set(entitySetName).insert(data)
+	if apiErr != nil {
+		return uuid.Nil, apiErr
+	}
+	return r.id, nil
+}
+
+// Records returns a copy of the records in the entity set of the default
+// company with their "@odata.etag".
+func (s *Server) Records(entitySetName string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.entitySets[entitySetName] {
+		return nil
+	}
+
+	set := s.entitySet(entitySetName)
+	records := make([]map[string]any, len(set.records))
+	for i, r := range set.records {
+		records[i] = r.object()
+	}
+	return records
+}
+
+// AddCompany adds a company and returns its id.
+func (s *Server) AddCompany(name string) uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := newCompany(uuid.New(), name)
+	s.companies = append(s.companies, c)
+	return c.id
+}
+
+// entitySet returns the entity set of the default company, adding
+// it if needed. Must be called with the lock held.
+func (s *Server) entitySet(name string) *entitySet {
+	s.entitySets[name] = true
+	return s.companies[0].set(name)
+}
+
+// company returns the company with the id. Must be called with the lock held.
+func (s *Server) company(id string) (*company, *Error) {
+	for _, c := range s.companies {
+		if strings.EqualFold(c.id.String(), id) {
+			return c, nil
+		}
+	}
+	return nil, notFound("The company %q does not exist.", id)
+}
+
+// TokenGetter is a fake bc.TokenGetter. It returns Token, or "fake-token" if empty.
+type TokenGetter struct {
+	Token bc.AccessToken
+}
+
+// GetToken implements the bc.TokenGetter interface.
+func (tg TokenGetter) GetToken(context.Context) (bc.AccessToken, error) {
+	if tg.Token == "" {
+		return "fake-token", nil
+	}
+	return tg.Token, nil
+}
+
+// Error is written as a bc.ErrorResponse. Return it from an [ActionFunc]
+// to respond with a BC error.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%d %s] %s", e.StatusCode, e.Code, e.Message)
+}
+
+func badRequest(format string, args ...any) *Error {
+	return &Error{http.StatusBadRequest, "BadRequest", fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...any) *Error {
+	return &Error{http.StatusNotFound, "BadRequest_NotFound", fmt.Sprintf(format, args...)}
+}
+
+func writeError(w http.ResponseWriter, err *Error) {
+	body := bc.ErrorResponse{Error: bc.ErrorResponseError{Code: err.Code, Message: err.Message}}
+	writeJSON(w, err.StatusCode, body)
+}
+
+// responseWriter remembers if the request asked for "odata.metadata=none".
+type responseWriter struct {
+	http.ResponseWriter
+	noMetadata bool
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	metadata := bc.MinimalODATAMetadata
+	if rw, ok := w.(*responseWriter); ok && rw.noMetadata {
+		// Like BC, leave out the "@odata.etag" without metadata
+		metadata = bc.NoODATAMetadata
+		v = withoutETags(v)
+	}
+
+	w.Header().Set("Content-Type", "application/json; "+metadata)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// withoutETags returns a copy of the JSON value without the "@odata.etag" fields.
+func withoutETags(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var copied any
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return v
+	}
+
+	var strip func(v any)
+	strip = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			delete(v, "@odata.etag")
+			for _, child := range v {
+				strip(child)
+			}
+		case []any:
+			for _, child := range v {
+				strip(child)
+			}
+		}
+	}
+	strip(copied)
+	return copied
+}
+
+// toObject marshals the value and unmarshals it into a JSON object.
+func toObject(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal record: %w", err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("record is not a JSON object: %w", err)
+	}
+	return data, nil
+}
+
+// resourcePath returns the company id and the segments of the path
+// after "companies({id})".
+func resourcePath(path string) (string, []string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		if id, ok := strings.CutPrefix(seg, "companies("); ok {
+			return strings.TrimSuffix(id, ")"), segments[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// parseSegment splits "name(id)" into the name and id.
+func parseSegment(seg string) (string, uuid.UUID, *Error) {
+	name, rest, ok := strings.Cut(seg, "(")
+	if !ok {
+		return seg, uuid.Nil, nil
+	}
+
+	idString := strings.TrimSuffix(rest, ")")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return "", uuid.Nil, badRequest("Invalid id %q in segment %q.", idString, seg)
+	}
+	return name, id, nil
+}
@@ -0,0 +1,297 @@
+package bcfake_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/bc/filter"
+	"github.com/google/uuid"
+)
+
+type customer struct {
+	bc.ETagged
+	ID                   uuid.UUID `json:"id"`
+	Number               string    `json:"number"`
+	DisplayName          string    `json:"displayName"`
+	Balance              float64   `json:"balance"`
+	Blocked              bool      `json:"blocked"`
+	CurrencyID           uuid.UUID `json:"currencyId"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+	Currency             *currency `json:"currency,omitempty"`
+	Contacts             []contact `json:"contacts,omitempty"`
+}
+
+func (c customer) Validate() error {
+	if c.ID == uuid.Nil {
+		return errors.New("id is empty")
+	}
+	return nil
+}
+
+type currency struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+}
+
+type contact struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customerId"`
+	Name       string    `json:"name"`
+}
+
+func newServer(t *testing.T) (*bcfake.Server, *bc.APIPage[customer]) {
+	t.Helper()
+
+	srv := bcfake.NewServer()
+	t.Cleanup(srv.Close)
+
+	for i, name := range []string{"Adatum", "Trey Research", "Alpine Ski House", "O'Brien Ltd"} {
+		_, err := srv.Insert("customers", map[string]any{
+			"number":      fmt.Sprintf("1%04d", i),
+			"displayName": name,
+			"balance":     float64(i * 100),
+			"blocked":     i == 3,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, bc.NewAPIPage[customer](client, "customers")
+}
+
+func TestServerCRUD(t *testing.T) {
+	_, page := newServer(t)
+	ctx := context.Background()
+
+	created, err := page.Create(ctx, map[string]any{"number": "20000", "displayName": "Fabrikam"}, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ETag == "" {
+		t.Error("expected an ETag on the created record")
+	}
+
+	got, err := page.Get(ctx, created.ID, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DisplayName != "Fabrikam" {
+		t.Errorf("wanted Fabrikam, got %s", got.DisplayName)
+	}
+
+	updated, err := page.UpdateIfMatch(ctx, created.ID, got.ETag, nil, map[string]any{"displayName": "Fabrikam Inc."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.DisplayName != "Fabrikam Inc." || updated.Number != "20000" {
+		t.Errorf("wanted merged record, got %+v", updated)
+	}
+
+	// The old ETag no longer matches
+	_, err = page.UpdateIfMatch(ctx, created.ID, got.ETag, nil, map[string]any{"displayName": "Stale"})
+	if !bc.IsPreconditionFailed(err) {
+		t.Errorf("wanted precondition failed, got %v", err)
+	}
+
+	if err := page.DeleteIfMatch(ctx, created.ID, got.ETag); !bc.IsPreconditionFailed(err) {
+		t.Errorf("wanted precondition failed, got %v", err)
+	}
+	if err := page.DeleteIfMatch(ctx, created.ID, updated.ETag); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = page.Get(ctx, created.ID, bc.GetOptions{})
+	if !bc.IsNotFound(err) {
+		t.Errorf("wanted not found, got %v", err)
+	}
+}
+
+func TestServerMetadata(t *testing.T) {
+	srv, page := newServer(t)
+	ctx := context.Background()
+
+	records, err := page.List(ctx, bc.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if r.ETag == "" {
+			t.Errorf("expected an ETag on %s", r.Number)
+		}
+	}
+
+	// Like BC, there is no "@odata.etag" without the metadata
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := client.NewRequest(ctx, bc.RequestOptions{
+		Method:        http.MethodGet,
+		EntitySetName: "customers",
+		Header:        http.Header{"Accept": {bc.AcceptJSONNoMetadata}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := bc.Decode[bc.APIListResponse[customer]](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range list.Value {
+		if r.ETag != "" {
+			t.Errorf("expected no ETag on %s, got %s", r.Number, r.ETag)
+		}
+	}
+}
+
+func TestServerList(t *testing.T) {
+	_, page := newServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts bc.ListOptions
+		want []string
+	}{
+		{"all", bc.ListOptions{}, []string{"10000", "10001", "10002", "10003"}},
+		{"eq", bc.ListOptions{Filter: filter.Eq("displayName", "O'Brien Ltd").String()}, []string{"10003"}},
+		{"and or", bc.ListOptions{Filter: filter.And(
+			filter.Or(filter.StartsWith("displayName", "A"), filter.Contains("displayName", "Research")),
+			filter.Ge("balance", 100),
+		).String()}, []string{"10001", "10002"}},
+		{"not bool", bc.ListOptions{Filter: filter.Not(filter.Eq("blocked", false)).String()}, []string{"10003"}},
+		{"orderby top skip", bc.ListOptions{OrderBy: []string{"balance desc"}, Top: 2, Skip: 1}, []string{"10002", "10001"}},
+		{"paged", bc.ListOptions{MaxPageSize: 1}, []string{"10000", "10001", "10002", "10003"}},
+		{"paged max records", bc.ListOptions{MaxPageSize: 3, MaxRecords: 2}, []string{"10000", "10001"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := page.List(ctx, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, r := range records {
+				got = append(got, r.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("wanted %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestServerFilterDateTime(t *testing.T) {
+	srv, page := newServer(t)
+	ctx := context.Background()
+
+	cutoff := time.Now()
+	id, err := srv.Insert("customers", map[string]any{"number": "30000", "lastModifiedDateTime": cutoff.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := page.List(ctx, bc.ListOptions{Filter: filter.Gt("lastModifiedDateTime", cutoff).String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ID != id {
+		t.Errorf("wanted only record %s, got %+v", id, records)
+	}
+}
+
+func TestServerExpandSelect(t *testing.T) {
+	srv, page := newServer(t)
+	ctx := context.Background()
+
+	currencyID, err := srv.Insert("currencies", currency{Code: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	customerID, err := srv.Insert("customers", map[string]any{"number": "40000", "currencyId": currencyID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ann", "Bob"} {
+		if _, err := srv.Insert("contacts", contact{CustomerID: customerID, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv.AddNavigation("customers", "currency", bcfake.Navigation{EntitySet: "currencies", ForeignKey: "currencyId"})
+	srv.AddNavigation("customers", "contacts", bcfake.Navigation{EntitySet: "contacts", ForeignKey: "customerId", Collection: true})
+
+	got, err := page.Get(ctx, customerID, bc.GetOptions{Expand: []string{"currency", "contacts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Currency == nil || got.Currency.Code != "EUR" {
+		t.Errorf("wanted currency EUR, got %+v", got.Currency)
+	}
+	if len(got.Contacts) != 2 {
+		t.Errorf("wanted 2 contacts, got %d", len(got.Contacts))
+	}
+
+	records, err := page.List(ctx, bc.ListOptions{Filter: filter.Eq("number", "40000").String(), Select: []string{"id", "number"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Number != "40000" || records[0].DisplayName != "" {
+		t.Errorf("wanted only id and number, got %+v", records)
+	}
+
+	_, err = page.Get(ctx, customerID, bc.GetOptions{Expand: []string{"unknown"}})
+	if !bc.IsValidationFailure(err) {
+		t.Errorf("wanted bad request, got %v", err)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	srv, page := newServer(t)
+	ctx := context.Background()
+
+	_, err := page.List(ctx, bc.ListOptions{Filter: "displayName eq"})
+	var apiErr bc.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("wanted 400 APIError for invalid filter, got %v", err)
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bc.NewAPIPage[customer](client, "unknown").List(ctx, bc.ListOptions{})
+	if !bc.IsNotFound(err) {
+		t.Errorf("wanted not found for unknown entity set, got %v", err)
+	}
+
+	existing := srv.Records("customers")[0]["id"]
+	_, err = page.Create(ctx, map[string]any{"id": existing}, bc.GetOptions{})
+	if !bc.IsConflict(err) {
+		t.Errorf("wanted conflict for duplicate id, got %v", err)
+	}
+
+	// A null body is not a record
+	_, err = page.Create(ctx, json.RawMessage("null"), bc.GetOptions{})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("wanted 400 APIError for null body, got %v", err)
+	}
+}
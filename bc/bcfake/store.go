@@ -0,0 +1,197 @@
+package bcfake
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// company holds the entity sets of a company.
+type company struct {
+	id      uuid.UUID
+	name    string
+	created string
+	sets    map[string]*entitySet
+}
+
+func newCompany(id uuid.UUID, name string) *company {
+	return &company{id: id, name: name, created: now(), sets: map[string]*entitySet{}}
+}
+
+// set returns the entity set, adding it if needed.
+func (c *company) set(name string) *entitySet {
+	set, ok := c.sets[name]
+	if !ok {
+		set = &entitySet{name: name, company: c}
+		c.sets[name] = set
+	}
+	return set
+}
+
+// object returns the company as returned by the companies API.
+func (c *company) object() map[string]any {
+	return map[string]any{
+		"id":                c.id.String(),
+		"systemVersion":     "26.0.0.0",
+		"name":              c.name,
+		"displayName":       c.name,
+		"businessProfileId": "",
+		"systemCreatedAt":   c.created,
+		"systemCreatedBy":   uuid.Nil.String(),
+		"systemModifiedAt":  c.created,
+		"systemModifiedBy":  uuid.Nil.String(),
+	}
+}
+
+// entitySet holds the records in insertion order.
+type entitySet struct {
+	name    string
+	company *company
+	records []*record
+}
+
+// record is a JSON object with its id and version for the ETag.
+type record struct {
+	id      uuid.UUID
+	data    map[string]any
+	version int
+	media   map[string]media
+}
+
+// media is the content of a media stream of a record.
+type media struct {
+	contentType string
+	data        []byte
+}
+
+// etag returns the weak ETag of the current version.
+func (r *record) etag() string {
+	return fmt.Sprintf(`W/"%d"`, r.version)
+}
+
+// object returns a copy of the data with the "@odata.etag".
+func (r *record) object() map[string]any {
+	obj := maps.Clone(r.data)
+	obj["@odata.etag"] = r.etag()
+	return obj
+}
+
+// setMedia replaces the media stream at the path.
+func (r *record) setMedia(path, contentType string, data []byte) {
+	if r.media == nil {
+		r.media = map[string]media{}
+	}
+	r.media[path] = media{contentType: contentType, data: data}
+}
+
+func (s *entitySet) find(id uuid.UUID) (int, *record) {
+	for i, r := range s.records {
+		if r.id == id {
+			return i, r
+		}
+	}
+	return -1, nil
+}
+
+func (s *entitySet) get(id uuid.UUID) (*record, *Error) {
+	_, r := s.find(id)
+	if r == nil {
+		return nil, notFound("The %s does not exist. Identification fields and values: Id='%s'", s.name, id)
+	}
+	return r, nil
+}
+
+// insert adds the record. It assigns an id if there is none and
+// sets lastModifiedDateTime if it is not set.
+func (s *entitySet) insert(data map[string]any) (*record, *Error) {
+	data = withoutAnnotations(data)
+
+	id := uuid.New()
+	if v, ok := data["id"].(string); ok && v != "" {
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			return nil, badRequest("Invalid id %q.", v)
+		}
+		id = parsed
+	}
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	if _, r := s.find(id); r != nil {
+		return nil, &Error{http.StatusConflict, "Internal_EntityWithSameKeyExists",
+			fmt.Sprintf("The record in table %s already exists. Identification fields and values: Id='%s'", s.name, id)}
+	}
+
+	data["id"] = id.String()
+	if _, ok := data["lastModifiedDateTime"]; !ok {
+		data["lastModifiedDateTime"] = now()
+	}
+
+	r := &record{id: id, data: data, version: 1}
+	s.records = append(s.records, r)
+	return r, nil
+}
+
+// update merges the fields into the record if the If-Match matches.
+func (s *entitySet) update(id uuid.UUID, ifMatch string, fields map[string]any) (*record, *Error) {
+	r, apiErr := s.get(id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkIfMatch(r, ifMatch); apiErr != nil {
+		return nil, apiErr
+	}
+
+	fields = withoutAnnotations(fields)
+	delete(fields, "id")
+	maps.Copy(r.data, fields)
+	r.data["lastModifiedDateTime"] = now()
+	r.version++
+	return r, nil
+}
+
+// delete removes the record if the If-Match matches.
+func (s *entitySet) delete(id uuid.UUID, ifMatch string) *Error {
+	i, r := s.find(id)
+	if r == nil {
+		return notFound("The %s does not exist. Identification fields and values: Id='%s'", s.name, id)
+	}
+	if apiErr := checkIfMatch(r, ifMatch); apiErr != nil {
+		return apiErr
+	}
+
+	s.records = append(s.records[:i], s.records[i+1:]...)
+	return nil
+}
+
+// checkIfMatch requires an If-Match of "*" or the current ETag.
+func checkIfMatch(r *record, ifMatch string) *Error {
+	if ifMatch == "" {
+		return badRequest("Could not validate the client concurrency token required by the service. Please provide a valid token in the client request.")
+	}
+	if ifMatch != "*" && ifMatch != r.etag() {
+		return &Error{http.StatusPreconditionFailed, "Request_EntityChanged",
+			"Another user has already changed the record."}
+	}
+	return nil
+}
+
+// withoutAnnotations returns a copy of the data without the "@odata." fields.
+func withoutAnnotations(data map[string]any) map[string]any {
+	clean := make(map[string]any, len(data))
+	for k, v := range data {
+		if !strings.HasPrefix(k, "@odata.") {
+			clean[k] = v
+		}
+	}
+	return clean
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
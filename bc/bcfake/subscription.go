@@ -0,0 +1,285 @@
+package bcfake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/google/uuid"
+)
+
+// subscriptionPath returns the id of "api/v2.0/subscriptions('{id}')", which is
+// empty for the collection. It returns false for other paths.
+func subscriptionPath(path string) (string, bool) {
+	prefix, last, _ := strings.Cut(strings.Trim(path, "/"), "/api/v2.0/")
+	if prefix == "" || strings.Contains(last, "/") {
+		return "", false
+	}
+
+	rest, ok := strings.CutPrefix(last, "subscriptions")
+	if !ok {
+		return "", false
+	}
+	if rest == "" {
+		return "", true
+	}
+
+	id, ok := strings.CutPrefix(rest, "('")
+	if !ok || !strings.HasSuffix(id, "')") {
+		return "", false
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(id, "')"), "''", "'"), true
+}
+
+// serveSubscriptions handles the subscriptions API. The notification URL is
+// validated without the lock, so the receiver can call back into the Server.
+func (s *Server) serveSubscriptions(w http.ResponseWriter, r *http.Request, id string) {
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		values := make([]map[string]any, len(s.subscriptions))
+		for i, sub := range s.subscriptions {
+			values[i] = sub.object()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"value": values})
+	case r.Method == http.MethodGet:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		_, sub, apiErr := s.subscription(id)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		writeJSON(w, http.StatusOK, sub.object())
+	case r.Method == http.MethodPost && id == "":
+		s.createSubscription(w, r)
+	case r.Method == http.MethodPatch && id != "":
+		s.renewSubscription(w, r, id)
+	case r.Method == http.MethodDelete && id != "":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		i, sub, apiErr := s.subscription(id)
+		if apiErr == nil {
+			apiErr = checkIfMatch(sub, r.Header.Get("If-Match"))
+		}
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		s.subscriptions = slices.Delete(s.subscriptions, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, &Error{http.StatusMethodNotAllowed, "BadRequest_MethodNotAllowed",
+			"The requested method is not allowed for the resource."})
+	}
+}
+
+func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
+	body, apiErr := decodeSubscription(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	if apiErr := validateNotificationURL(r.Context(), body.NotificationURL); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := uuid.New()
+	data := map[string]any{
+		"subscriptionId":       strings.ReplaceAll(id.String(), "-", ""),
+		"notificationUrl":      body.NotificationURL,
+		"resource":             body.Resource,
+		"userId":               uuid.NewString(),
+		"clientState":          body.ClientState,
+		"lastModifiedDateTime": now(),
+		"expirationDateTime":   expiration(),
+	}
+
+	sub := &record{id: id, data: data, version: 1}
+	s.subscriptions = append(s.subscriptions, sub)
+	writeJSON(w, http.StatusCreated, sub.object())
+}
+
+func (s *Server) renewSubscription(w http.ResponseWriter, r *http.Request, id string) {
+	body, apiErr := decodeSubscription(r)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	if apiErr := validateNotificationURL(r.Context(), body.NotificationURL); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, sub, apiErr := s.subscription(id)
+	if apiErr == nil {
+		apiErr = checkIfMatch(sub, r.Header.Get("If-Match"))
+	}
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+
+	maps.Copy(sub.data, map[string]any{
+		"notificationUrl":      body.NotificationURL,
+		"resource":             body.Resource,
+		"clientState":          body.ClientState,
+		"lastModifiedDateTime": now(),
+		"expirationDateTime":   expiration(),
+	})
+	sub.version++
+	writeJSON(w, http.StatusOK, sub.object())
+}
+
+// subscription finds the subscription. Must be called with the lock held.
+func (s *Server) subscription(id string) (int, *record, *Error) {
+	for i, sub := range s.subscriptions {
+		if sub.data["subscriptionId"] == id {
+			return i, sub, nil
+		}
+	}
+	return -1, nil, notFound("The subscription %q does not exist.", id)
+}
+
+func decodeSubscription(r *http.Request) (bc.SubscriptionRequest, *Error) {
+	var body bc.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return body, badRequest("Invalid JSON body: %s", err)
+	}
+	if err := body.Validate(); err != nil {
+		return body, badRequest("Invalid subscription: %s", err)
+	}
+	return body, nil
+}
+
+// validateNotificationURL posts a validationToken to the notification URL
+// like BC does, which must respond with the token.
+func validateNotificationURL(ctx context.Context, notificationURL string) *Error {
+	failed := badRequest("The subscription could not be validated by the notification URL %q.", notificationURL)
+
+	u, err := url.Parse(notificationURL)
+	if err != nil {
+		return failed
+	}
+
+	token := uuid.NewString()
+	q := u.Query()
+	q.Set("validationToken", token)
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return failed
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return failed
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil || res.StatusCode != http.StatusOK || strings.TrimSpace(string(b)) != token {
+		return failed
+	}
+	return nil
+}
+
+// Notify posts a notification of the change to the subscriptions of the resource,
+// like BC does after a change. The resource is the path of a record, e.g. the
+// SubscriptionResource of the Client followed by "({id})", or of an entity set
+// with a $filter for a "collection" change. It returns an error if a notification
+// URL does not respond with a 2xx status.
+func (s *Server) Notify(ctx context.Context, changeType, resource string) error {
+	resource = strings.TrimPrefix(resource, "/")
+	path, _, _ := strings.Cut(resource, "?")
+
+	type notification struct {
+		url  string
+		body map[string]any
+	}
+
+	s.mu.Lock()
+	var notifications []notification
+	for _, sub := range s.subscriptions {
+		subResource := strings.TrimPrefix(sub.data["resource"].(string), "/")
+		if path != subResource && !strings.HasPrefix(path, subResource+"(") {
+			continue
+		}
+
+		notifications = append(notifications, notification{
+			url: sub.data["notificationUrl"].(string),
+			body: map[string]any{"value": []map[string]any{{
+				"subscriptionId":       sub.data["subscriptionId"],
+				"clientState":          sub.data["clientState"],
+				"expirationDateTime":   sub.data["expirationDateTime"],
+				"resource":             resource,
+				"changeType":           changeType,
+				"lastModifiedDateTime": now(),
+			}}},
+		})
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, n := range notifications {
+		if err := postNotification(ctx, n.url, n.body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func postNotification(ctx context.Context, notificationURL string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notificationURL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post notification: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("post notification to %s: status %d", notificationURL, res.StatusCode)
+	}
+	return nil
+}
+
+func expiration() string {
+	return time.Now().UTC().Add(bc.SubscriptionLifetime).Format(time.RFC3339Nano)
+}
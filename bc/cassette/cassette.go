@@ -0,0 +1,318 @@
+// Package cassette records the HTTP interactions of a bc.Client to a file
+// and replays them, so integration tests can run without credentials or
+// a network.
+//
+// In ModeRecord the requests are sent to BC and every interaction is kept
+// until Save writes the cassette. The Authorization header is never saved and
+// the values passed to WithScrub, such as the tenant and company IDs, are
+// replaced in the URLs, headers and bodies. Values passed to WithURLScrub,
+// such as the environment name, are only replaced in URLs. In ModeReplay the requests are
+// matched on method, path and normalized OData query against the cassette,
+// in the order they were recorded, and nothing is sent.
+//
+//	rec, err := cassette.New("testdata/items.json", cassette.ModeReplay,
+//		cassette.WithScrub(realTenantID, fakeTenantID))
+//	client, err := bc.NewClient(config, bc.WithHTTPClient(rec.Client()))
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Mode is whether the Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay serves the interactions from the cassette.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records the interactions.
+	ModeRecord
+)
+
+// ErrNoMatch is returned by RoundTrip in ModeReplay when no unused
+// interaction in the cassette matches the request.
+var ErrNoMatch = errors.New("cassette: no matching interaction")
+
+// Cassette is the file format of the recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded http.Request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded http.Response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	scrubs    []scrub
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+type scrub struct {
+	re *regexp.Regexp
+	// replacement is the template for ReplaceAllString.
+	replacement string
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the http.RoundTripper used in ModeRecord.
+// Defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithScrub replaces every occurrence of value with replacement in the recorded
+// interactions. Requests are scrubbed the same way before they are matched
+// in ModeReplay. A GUID is matched case-insensitively, as the same GUID can be
+// written in uppercase in one place and lowercase in another. An empty value is ignored.
+func WithScrub(value, replacement string) Option {
+	return func(r *Recorder) {
+		if value == "" {
+			return
+		}
+		pattern := regexp.QuoteMeta(value)
+		if _, err := uuid.Parse(value); err == nil {
+			pattern = "(?i)" + pattern
+		}
+		r.scrubs = append(r.scrubs, scrub{regexp.MustCompile(pattern), escapeTemplate(replacement)})
+	}
+}
+
+// WithURLScrub is like WithScrub, but only replaces the value where it is a host or
+// a path segment of a URL, e.g. the environment in "/v2.0/{tenant}/{environment}/api".
+// Use it for values that are common words, so records that contain them are kept.
+// An empty value is ignored.
+func WithURLScrub(value, replacement string) Option {
+	return func(r *Recorder) {
+		if value != "" {
+			re := regexp.MustCompile(`/` + regexp.QuoteMeta(value) + `([/?#:"'\s]|$)`)
+			r.scrubs = append(r.scrubs, scrub{re, "/" + escapeTemplate(replacement) + "${1}"})
+		}
+	}
+}
+
+// New creates a Recorder for the cassette at path. In ModeReplay the cassette
+// is read and the error matches os.ErrNotExist if there is no cassette.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("decode cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Client returns an http.Client that uses the Recorder as its transport,
+// for use with bc.WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: read request body: %w", err)
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := readBody(&res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: read response body: %w", err)
+	}
+
+	header := req.Header.Clone()
+	header.Del("Authorization")
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    r.scrub(req.URL.String()),
+			Header: r.scrubHeader(header),
+			Body:   r.scrub(reqBody),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     r.scrubHeader(res.Header.Clone()),
+			Body:       r.scrub(resBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// replay returns the response of the first unused interaction that matches.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key, err := matchKey(req.Method, r.scrub(req.URL.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+
+		recorded, err := matchKey(interaction.Request.Method, interaction.Request.URL)
+		if err != nil || recorded != key {
+			continue
+		}
+
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoMatch, key)
+}
+
+// Save writes the recorded interactions to the cassette, creating the
+// directory if needed. It does nothing in ModeReplay.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) scrub(s string) string {
+	for _, sc := range r.scrubs {
+		s = sc.re.ReplaceAllString(s, sc.replacement)
+	}
+	return s
+}
+
+// escapeTemplate escapes the "$" in a replacement so it is used literally
+// in a regexp template.
+func escapeTemplate(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+func (r *Recorder) scrubHeader(h http.Header) http.Header {
+	for k, values := range h {
+		for i, v := range values {
+			values[i] = r.scrub(v)
+		}
+		h[k] = values
+	}
+	return h
+}
+
+// matchKey is the method, path and query with the params sorted, so the
+// order the params were added does not matter.
+func matchKey(method, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("cassette: %w", err)
+	}
+	return method + " " + u.Path + "?" + u.Query().Encode(), nil
+}
+
+// readBody reads the body and replaces it so it can be read again.
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+
+	b, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+
+	*body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
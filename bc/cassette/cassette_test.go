@@ -0,0 +1,174 @@
+package cassette_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc/cassette"
+)
+
+const (
+	realTenant = "11111111-2222-3333-4444-555555555555"
+	fakeTenant = "00000000-0000-0000-0000-000000000001"
+)
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"value":[],"@odata.nextLink":"https://host/v2.0/`+realTenant+`/items?$skiptoken=`+r.URL.Query().Get("$top")+`"}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "items.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, cassette.WithScrub(realTenant, fakeTenant))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, top := range []string{"1", "2"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v2.0/"+realTenant+"/items?$top="+top+"&$filter=number%20eq%20'1'", nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		res, err := rec.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{realTenant, "secret-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	replay, err := cassette.New(path, cassette.ModeReplay, cassette.WithScrub(realTenant, fakeTenant))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Query params in a different order, and the scrubbed tenant, still match
+	for _, top := range []string{"2", "1"} {
+		res, err := replay.Client().Get(srv.URL + "/v2.0/" + fakeTenant + "/items?$filter=number%20eq%20'1'&$top=" + top)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != 200 {
+			t.Errorf("wanted status 200, got %d", res.StatusCode)
+		}
+		if want := "$skiptoken=" + top; !strings.Contains(string(body), want) {
+			t.Errorf("wanted body with %s, got %s", want, body)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("replay sent requests, wanted 2 calls, got %d", calls)
+	}
+
+	// Every interaction is used once
+	_, err = replay.Client().Get(srv.URL + "/v2.0/" + fakeTenant + "/items?$top=1&$filter=number%20eq%20'1'")
+	if !errors.Is(err, cassette.ErrNoMatch) {
+		t.Errorf("wanted ErrNoMatch, got %v", err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := cassette.New(filepath.Join(t.TempDir(), "missing.json"), cassette.ModeReplay)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("wanted os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestScrubGUIDCase(t *testing.T) {
+	upper := strings.ToUpper(realTenant[:8]) + realTenant[8:]
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"tenantId":"`+strings.ToUpper(realTenant)+`"}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "items.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, cassette.WithScrub(upper, fakeTenant))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := rec.Client().Get(srv.URL + "/v2.0/" + realTenant + "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strings.ToLower(string(data)), realTenant) {
+		t.Errorf("cassette contains the tenant: %s", data)
+	}
+	if n := strings.Count(string(data), fakeTenant); n != 2 {
+		t.Errorf("wanted the tenant replaced twice, got %d in %s", n, data)
+	}
+}
+
+func TestURLScrub(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"@odata.context":"https://host/v2.0/tenant/production/api/v2.0/$metadata#items","value":[{"description":"production line"}]}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "items.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, cassette.WithURLScrub("production", "environment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := rec.Client().Get(srv.URL + "/v2.0/tenant/production/api/v2.0/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/tenant/environment/api/v2.0/items", "/tenant/environment/api/v2.0/$metadata", "production line"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("wanted cassette with %q, got %s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "/production") {
+		t.Errorf("cassette contains the environment in a URL: %s", data)
+	}
+}
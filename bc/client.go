@@ -24,6 +24,10 @@ type Client struct {
 	baseURL    *url.URL
 	config     ClientConfig
 	logger     *slog.Logger
+
+	retryPolicy        *RetryPolicy
+	rateLimiter        *RateLimiter
+	concurrencyLimiter *ConcurrencyLimiter
 }
 
 // The required configuration options for the Client.
@@ -33,19 +37,50 @@ type ClientConfig struct {
 	TenantID string
 	// CompanyID is the BC company within the environment.
 	CompanyID string
-	// Environment must be a non-empty string.
+	// Environment must be a non-empty string, unless ServerInstance is set.
 	Environment string
 	// APIEndpoint must be either "v2.0" or the format
 	//"<publisher>/<group>/<version>".
 	APIEndpoint string
-	// ClientID is also known as the application ID.
+	// ClientID is also known as the application ID. With ManagedIdentity
+	// it is the client ID of a user-assigned identity and can be empty.
 	ClientID string
+
+	// Exactly one of the credentials below is required, unless
+	// a TokenGetter is set with WithAuthClient.
+
 	//ClientSecret is the MSAL client secret for the application.
 	ClientSecret string
+	// CertificateFile is the path to a PEM or PFX certificate for the application.
+	CertificateFile string
+	// CertificatePassword decrypts the CertificateFile if it is encrypted.
+	CertificatePassword string
+	// FederatedTokenFile is the path to a federated token that is used as the
+	// client assertion, e.g. the Kubernetes AZURE_FEDERATED_TOKEN_FILE.
+	FederatedTokenFile string
+	// ManagedIdentity uses the Azure managed identity of the host.
+	ManagedIdentity bool
+
+	// APIHost is the scheme and host of the API, e.g. "https://server:7048"
+	// or a local fake server. Defaults to DefaultAPIHost.
+	APIHost string
+	// ServerInstance is the on-premises server instance, e.g. "BC". If set the
+	// on-premises URL layout is used and Environment is not required.
+	ServerInstance string
+	// AuthorityHost is the Entra authority host. Defaults to DefaultAuthorityHost.
+	AuthorityHost string
+	// Scope is the scope of the access token. Defaults to DefaultScope.
+	Scope string
 }
 
 // Validates that the params are all in correct format.
 func (cc ClientConfig) Validate() error {
+	return cc.validate(true)
+}
+
+// validate checks the params. The credential is not required if
+// the TokenGetter was provided.
+func (cc ClientConfig) validate(requireCredential bool) error {
 	var errs []string
 
 	if _, err := uuid.Parse(cc.TenantID); err != nil {
@@ -56,16 +91,43 @@ func (cc ClientConfig) Validate() error {
 		errs = append(errs, fmt.Sprintf("CompanyID: %s", err))
 	}
 
-	if err := stringNotEmpty(cc.Environment); err != nil {
-		errs = append(errs, fmt.Sprintf("Environment: %s", err))
+	if cc.ServerInstance == "" {
+		if err := stringNotEmpty(cc.Environment); err != nil {
+			errs = append(errs, fmt.Sprintf("Environment: %s", err))
+		}
 	}
 
-	if _, err := uuid.Parse(cc.ClientID); err != nil {
-		errs = append(errs, fmt.Sprintf("ClientID: %s", err))
+	if cc.APIHost != "" {
+		if err := validateHost(cc.APIHost); err != nil {
+			errs = append(errs, fmt.Sprintf("APIHost: %s", err))
+		}
 	}
 
-	if err := stringNotEmpty(cc.ClientSecret); err != nil {
-		errs = append(errs, fmt.Sprintf("ClientSecret: %s", err))
+	if cc.AuthorityHost != "" {
+		if err := validateHost(cc.AuthorityHost); err != nil {
+			errs = append(errs, fmt.Sprintf("AuthorityHost: %s", err))
+		}
+	}
+
+	if cc.ClientID != "" || !cc.ManagedIdentity {
+		if _, err := uuid.Parse(cc.ClientID); err != nil {
+			errs = append(errs, fmt.Sprintf("ClientID: %s", err))
+		}
+	}
+
+	credentials := 0
+	for _, set := range []bool{cc.ClientSecret != "", cc.CertificateFile != "", cc.FederatedTokenFile != "", cc.ManagedIdentity} {
+		if set {
+			credentials++
+		}
+	}
+
+	if credentials > 1 {
+		errs = append(errs, "credentials: only one of ClientSecret, CertificateFile, FederatedTokenFile or ManagedIdentity can be set")
+	}
+
+	if credentials == 0 && requireCredential {
+		errs = append(errs, fmt.Sprintf("ClientSecret: %s", ErrorEmptyString))
 	}
 
 	if cc.APIEndpoint == "v2.0" {
@@ -89,11 +151,21 @@ func (cc ClientConfig) Validate() error {
 }
 
 // NewClient creates a [Client] with configuration params and optional configuration with functional options.
-// Available options are [WithAuthClient], [WithLogger], [WithHTTPClient].
+// Available options are [WithAuthClient], [WithLogger], [WithHTTPClient], [WithRetry],
+// [WithRateLimit], [WithMaxConcurrency].
 func NewClient(config ClientConfig, opts ...ClientOption) (*Client, error) {
 
-	// Validate params
-	if err := config.Validate(); err != nil {
+	client := &Client{
+		config: config,
+	}
+
+	// Apply the optional functions to the client
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	// Validate params, the credential is not needed if there is an authClient
+	if err := config.validate(client.authClient == nil); err != nil {
 		return nil, fmt.Errorf("validate config: \n%w", err)
 	}
 
@@ -102,19 +174,10 @@ func NewClient(config ClientConfig, opts ...ClientOption) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	client := &Client{
-		baseURL: baseURL,
-		config:  config,
-	}
-
-	// Apply the optional functions to the client
-	for _, opt := range opts {
-		opt(client)
-	}
+	client.baseURL = baseURL
 
 	if client.authClient == nil {
-		ac, err := NewAuth(config.TenantID, config.ClientID, config.ClientSecret)
+		ac, err := newAuthFromConfig(config)
 		if err != nil {
 			return nil, err
 		}
@@ -124,9 +187,36 @@ func NewClient(config ClientConfig, opts ...ClientOption) (*Client, error) {
 	client.logger = cmp.Or(client.logger, slog.Default())
 	client.baseClient = cmp.Or(client.baseClient, &http.Client{Timeout: 20 * time.Second})
 
+	// Log the background token refreshes with the logger of the Client
+	if ctg, ok := client.authClient.(*CachingTokenGetter); ok {
+		ctg.setLogger(client.logger)
+	}
+
 	return client, nil
 }
 
+// newAuthFromConfig creates the TokenGetter for the credential in the config.
+func newAuthFromConfig(config ClientConfig) (TokenGetter, error) {
+	var opts []AuthOption
+	if config.AuthorityHost != "" {
+		opts = append(opts, WithAuthorityHost(config.AuthorityHost))
+	}
+	if config.Scope != "" {
+		opts = append(opts, WithScope(config.Scope))
+	}
+
+	switch {
+	case config.CertificateFile != "":
+		return NewAuthFromCertificateFile(config.TenantID, config.ClientID, config.CertificateFile, config.CertificatePassword, opts...)
+	case config.FederatedTokenFile != "":
+		return NewAuthFromAssertion(config.TenantID, config.ClientID, AssertionFromFile(config.FederatedTokenFile), opts...)
+	case config.ManagedIdentity:
+		return NewManagedIdentityAuth(config.ClientID, opts...)
+	default:
+		return NewAuth(config.TenantID, config.ClientID, config.ClientSecret, opts...)
+	}
+}
+
 // APIEndpoint returns the API endpoint, either the version for a common endpoint
 // or the <publisher>/<group>/<version> if an extension API.
 func (c *Client) APIEndpoint() string {
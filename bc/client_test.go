@@ -159,6 +159,29 @@ func TestConfig(t *testing.T) {
 	invalidGUID := validConfig
 	invalidGUID.TenantID = "NOT A GUID"
 
+	certificate := validConfig
+	certificate.ClientSecret = ""
+	certificate.CertificateFile = "cert.pem"
+
+	twoCredentials := validConfig
+	twoCredentials.FederatedTokenFile = "token"
+
+	systemAssigned := validConfig
+	systemAssigned.ClientSecret = ""
+	systemAssigned.ClientID = ""
+	systemAssigned.ManagedIdentity = true
+
+	noCredential := validConfig
+	noCredential.ClientSecret = ""
+
+	onPremises := validConfig
+	onPremises.Environment = ""
+	onPremises.APIHost = "https://server:7048"
+	onPremises.ServerInstance = "BC"
+
+	invalidHost := validConfig
+	invalidHost.APIHost = "server:7048"
+
 	type testCase struct {
 		name       string
 		config     bc.ClientConfig
@@ -170,6 +193,13 @@ func TestConfig(t *testing.T) {
 		{"empty", emptyConfig, false},
 		{"missing CompanyID", missingCompanyID, false},
 		{"invalid GUID", invalidGUID, false},
+		{"certificate", certificate, true},
+		{"two credentials", twoCredentials, false},
+		{"system assigned identity", systemAssigned, true},
+		// The TokenGetter is provided, so the credential is not needed
+		{"no credential", noCredential, true},
+		{"on-premises", onPremises, true},
+		{"invalid APIHost", invalidHost, false},
 	}
 
 	for _, test := range table {
@@ -182,3 +212,17 @@ func TestConfig(t *testing.T) {
 	}
 
 }
+
+func TestConfigValidateCredential(t *testing.T) {
+	config := fakeConfig
+	config.ClientSecret = ""
+
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for missing credential, got nil")
+	}
+
+	config.FederatedTokenFile = "token"
+	if err := config.Validate(); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
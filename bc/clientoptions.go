@@ -29,3 +29,27 @@ func WithAuthClient(authClient TokenGetter) ClientOption {
 		client.authClient = authClient
 	}
 }
+
+// WithRetry retries throttled and transient responses according to the [RetryPolicy].
+// Use [DefaultRetryPolicy] for sensible defaults.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(client *Client) {
+		client.retryPolicy = &policy
+	}
+}
+
+// WithRateLimit limits the rate of requests sent by the client. Pass the same
+// [RateLimiter] to every Client that targets the same environment.
+func WithRateLimit(limiter *RateLimiter) ClientOption {
+	return func(client *Client) {
+		client.rateLimiter = limiter
+	}
+}
+
+// WithMaxConcurrency limits the number of requests in flight. Pass the same
+// [ConcurrencyLimiter] to every Client that targets the same environment.
+func WithMaxConcurrency(limiter *ConcurrencyLimiter) ClientOption {
+	return func(client *Client) {
+		client.concurrencyLimiter = limiter
+	}
+}
@@ -0,0 +1,74 @@
+package bc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Company is a company in the environment, as returned by the companies API.
+type Company struct {
+	ID                uuid.UUID `json:"id"`
+	SystemVersion     string    `json:"systemVersion"`
+	Name              string    `json:"name"`
+	DisplayName       string    `json:"displayName"`
+	BusinessProfileID string    `json:"businessProfileId"`
+	SystemCreatedAt   time.Time `json:"systemCreatedAt"`
+	SystemCreatedBy   uuid.UUID `json:"systemCreatedBy"`
+	SystemModifiedAt  time.Time `json:"systemModifiedAt"`
+	SystemModifiedBy  uuid.UUID `json:"systemModifiedBy"`
+}
+
+// Validate implements the Validator interface.
+func (c Company) Validate() error {
+	if c.ID == uuid.Nil {
+		return errors.New("company id is empty")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("company %s: name is empty", c.ID)
+	}
+	return nil
+}
+
+// Companies makes a GET request to the companies of the API endpoint
+// and returns all of them. The company of the Client does not matter.
+func (c *Client) Companies(ctx context.Context) ([]Company, error) {
+	companiesURL := c.apiRootURL()
+	companiesURL.Path += "/companies"
+
+	req, err := c.newRequestURL(ctx, http.MethodGet, companiesURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+	req.Header.Set("Data-Access-Intent", DataAccessReadOnly)
+
+	return listAll[Company](c, req, 0)
+}
+
+// WithCompany returns a copy of the Client for another company. The copy shares
+// the HTTP client, TokenGetter, logger, retry policy and limiters, so it is cheap
+// to create one per company. The config is not validated again.
+func (c *Client) WithCompany(id uuid.UUID) *Client {
+	if id == uuid.Nil {
+		panic("with company: id is empty")
+	}
+
+	companyClient := *c
+	companyClient.config.CompanyID = id.String()
+
+	baseURL := c.apiRootURL()
+	baseURL.Path += fmt.Sprintf("/companies(%s)", id)
+	companyClient.baseURL = &baseURL
+
+	return &companyClient
+}
+
+// CompanyID returns the id of the company of the Client.
+func (c *Client) CompanyID() uuid.UUID {
+	id, _ := uuid.Parse(c.config.CompanyID)
+	return id
+}
@@ -0,0 +1,85 @@
+package bc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/google/uuid"
+)
+
+func TestCompanies(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	otherID := srv.AddCompany("Other Company")
+	if _, err := srv.Insert("items", fakeETagEntity{Number: "DEFAULT"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.InsertInto(otherID, "items", fakeETagEntity{Number: "OTHER"}); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	companies, err := client.Companies(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(companies) != 2 {
+		t.Fatalf("wanted 2 companies, got %d", len(companies))
+	}
+	if companies[1].ID != otherID || companies[1].Name != "Other Company" {
+		t.Errorf("wanted Other Company %s, got %+v", otherID, companies[1])
+	}
+
+	other := client.WithCompany(otherID)
+	if other.CompanyID() != otherID {
+		t.Errorf("wanted company %s, got %s", otherID, other.CompanyID())
+	}
+	if other.BaseClient() != client.BaseClient() || other.Logger() != client.Logger() {
+		t.Error("expected the HTTP client and logger to be shared")
+	}
+
+	// Each client reads its own company
+	for _, tt := range []struct {
+		client *bc.Client
+		want   string
+	}{
+		{client, "DEFAULT"},
+		{other, "OTHER"},
+	} {
+		items, err := bc.NewAPIPage[fakeETagEntity](tt.client, "items").List(ctx, bc.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 1 || items[0].Number != tt.want {
+			t.Errorf("wanted only %s, got %+v", tt.want, items)
+		}
+	}
+
+	// Unknown company
+	_, err = bc.NewAPIPage[fakeETagEntity](client.WithCompany(uuid.New()), "items").List(ctx, bc.ListOptions{})
+	if !bc.IsNotFound(err) {
+		t.Errorf("wanted not found, got %v", err)
+	}
+}
+
+func TestWithCompanyPanic(t *testing.T) {
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic, got nil")
+		}
+	}()
+	client.WithCompany(uuid.Nil)
+}
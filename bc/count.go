@@ -0,0 +1,67 @@
+package bc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeText is the Content-Type of a "$count" response.
+const ContentTypeText = "text/plain"
+
+// countRecords makes a GET request to the "$count" of the entity set
+// and parses the number in the text response.
+func countRecords(ctx context.Context, c *Client, entitySetName string, filterString string) (int, error) {
+	qp := QueryParams{}
+	if filterString != "" {
+		qp["$filter"] = filterString
+	}
+
+	opts := RequestOptions{
+		Method:        http.MethodGet,
+		EntitySetName: entitySetName,
+		Path:          "$count",
+		QueryParams:   qp,
+	}
+	req, err := c.NewRequest(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	// The count is text but errors are still JSON
+	req.Header.Set("Accept", ContentTypeText+", "+AcceptJSONNoMetadata)
+
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed during request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		err := decodeErrorResponse(res)
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			c.logger.Debug("API server returned error response.", "error", srvErr)
+			return 0, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read Response.Body: %w", err)
+	}
+
+	// Trim the byte order mark that BC can send before the number
+	text := strings.TrimSpace(strings.TrimPrefix(string(b), "\ufeff"))
+	count, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode response: invalid count %q", text)
+	}
+
+	return count, nil
+}
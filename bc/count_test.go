@@ -0,0 +1,94 @@
+package bc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+)
+
+func newCountServer(t *testing.T) (*bcfake.Server, *bc.APIPage[fakeETagEntity]) {
+	t.Helper()
+
+	srv := bcfake.NewServer()
+	t.Cleanup(srv.Close)
+
+	for i := range 5 {
+		if _, err := srv.Insert("items", fakeETagEntity{Number: fmt.Sprintf("ITEM%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, bc.NewAPIPage[fakeETagEntity](client, "items")
+}
+
+func TestAPIPageCount(t *testing.T) {
+	_, page := newCountServer(t)
+	ctx := context.Background()
+
+	count, err := page.Count(ctx, bc.ListOptions{Top: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("wanted 5, got %d", count)
+	}
+
+	page.BaseFilter = "number ne 'ITEM0'"
+	count, err = page.Count(ctx, bc.ListOptions{Filter: "number ne 'ITEM1'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("wanted 3 with the base filter and filter, got %d", count)
+	}
+}
+
+func TestAPIPageListPage(t *testing.T) {
+	_, page := newCountServer(t)
+	ctx := context.Background()
+
+	opts := bc.ListOptions{Count: true, MaxPageSize: 2, OrderBy: []string{"number"}}
+	first, err := page.ListPage(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Value) != 2 || first.NextLink == "" {
+		t.Fatalf("wanted 2 records and a next link, got %d %q", len(first.Value), first.NextLink)
+	}
+	if first.Count == nil || *first.Count != 5 {
+		t.Errorf("wanted count 5, got %v", first.Count)
+	}
+
+	second, err := page.NextPage(ctx, first.NextLink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Value) != 2 || second.Value[0].Number != "ITEM2" {
+		t.Errorf("wanted ITEM2 and ITEM3, got %+v", second.Value)
+	}
+
+	// The count ignores Top and Skip
+	paged, err := page.ListPage(ctx, bc.ListOptions{Count: true, Top: 2, Skip: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paged.Value) != 1 || paged.Count == nil || *paged.Count != 5 {
+		t.Errorf("wanted 1 record with count 5, got %d %v", len(paged.Value), paged.Count)
+	}
+
+	// No count unless requested
+	plain, err := page.ListPage(ctx, bc.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Count != nil {
+		t.Errorf("wanted no count, got %d", *plain.Count)
+	}
+}
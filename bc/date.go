@@ -41,6 +41,11 @@ func (d Date) String() string {
 	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
 }
 
+// ODataLiteral formats it 'YYYY-MM-DD' without quotes, as used in a $filter.
+func (d Date) ODataLiteral() string {
+	return d.String()
+}
+
 // Time returns a time.Time representing the Date at UTC time 00:00.
 func (d Date) TimeUTC() time.Time {
 	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
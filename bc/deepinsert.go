@@ -0,0 +1,140 @@
+package bc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// rawObject is a JSON object whose fields are decoded later.
+type rawObject map[string]json.RawMessage
+
+func (rawObject) Validate() error {
+	return nil
+}
+
+// CreateWithLines makes a deep insert POST request that creates the header
+// and its lines in one request, e.g. a sales order with its "salesOrderLines".
+// The lines are added to the header body under the navigation property, and the
+// navigation is added to the $expand so the created lines are returned.
+// It returns the created header H and lines L. BC creates all or nothing.
+//
+//	order, lines, err := bc.CreateWithLines[SalesOrderLine](ctx, orders, header, "salesOrderLines", newLines)
+func CreateWithLines[L Validator, H Validator, B any](ctx context.Context, a *APIPage[H], header any, navigation string, lines []B) (H, []L, error) {
+	var v H
+
+	if navigation == "" {
+		return v, nil, errors.New("create with lines: navigation is empty")
+	}
+
+	body, err := deepInsertBody(header, navigation, lines)
+	if err != nil {
+		return v, nil, fmt.Errorf("create with lines: %w", err)
+	}
+
+	// The lines are only returned when expanded
+	expands := a.BaseExpand
+	if !slices.Contains(expands, navigation) {
+		expands = slices.Concat(a.BaseExpand, []string{navigation})
+	}
+	qp := QueryParams{"$expand": strings.Join(expands, ",")}
+
+	reqOpts := RequestOptions{
+		Method:        http.MethodPost,
+		EntitySetName: a.entitySetName,
+		QueryParams:   qp,
+		Body:          body,
+	}
+	req, err := a.client.NewRequest(ctx, reqOpts)
+	if err != nil {
+		return v, nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	a.client.logger.Debug("Request initialized.", "url", req.URL.String(), "method", req.Method, "lines", len(lines))
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return v, nil, fmt.Errorf("failed during request: %w", err)
+	}
+
+	obj, err := Decode[rawObject](res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			a.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return v, nil, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return v, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	v, created, err := decodeDeepInsert[L, H](obj, navigation)
+	if err != nil {
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return v, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	a.client.logger.Debug(fmt.Sprintf("Successfully created %T record.", v), "lines", len(created))
+	return v, created, nil
+}
+
+// deepInsertBody marshals the header into a JSON object and adds the lines.
+func deepInsertBody[B any](header any, navigation string, lines []B) (map[string]any, error) {
+	b, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal header: %w", err)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(b, &body); err != nil || body == nil {
+		return nil, fmt.Errorf("header must be a JSON object, got %s", b)
+	}
+
+	if _, ok := body[navigation]; ok {
+		return nil, fmt.Errorf("header already has the field %q", navigation)
+	}
+
+	if lines == nil {
+		lines = []B{}
+	}
+	body[navigation] = lines
+	return body, nil
+}
+
+// decodeDeepInsert decodes and validates the header and the expanded lines.
+func decodeDeepInsert[L Validator, H Validator](obj rawObject, navigation string) (H, []L, error) {
+	var v H
+	var lines []L
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return v, nil, err
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, nil, fmt.Errorf("could not decode %T: %w", v, err)
+	}
+	if err := v.Validate(); err != nil {
+		return v, nil, fmt.Errorf("failed validation of %T: %w", v, err)
+	}
+
+	raw, ok := obj[navigation]
+	if !ok {
+		return v, nil, fmt.Errorf("response has no %q field", navigation)
+	}
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return v, nil, fmt.Errorf("could not decode %T: %w", lines, err)
+	}
+
+	for index, line := range lines {
+		if err := line.Validate(); err != nil {
+			return v, nil, fmt.Errorf("failed validation of %T at index %d: %w", line, index, err)
+		}
+	}
+	return v, lines, nil
+}
@@ -0,0 +1,125 @@
+package bc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/google/uuid"
+)
+
+type newOrderLine struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity,omitempty"`
+}
+
+type createdOrderLine struct {
+	ID          uuid.UUID `json:"id"`
+	DocumentID  uuid.UUID `json:"documentId"`
+	Description string    `json:"description"`
+	Quantity    int       `json:"quantity"`
+}
+
+func (l createdOrderLine) Validate() error {
+	if l.Description == "" {
+		return errors.New("description is empty")
+	}
+	return nil
+}
+
+func newDeepInsertServer(t *testing.T) (*bcfake.Server, *bc.APIPage[fakeOrder]) {
+	t.Helper()
+
+	srv := bcfake.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddNavigation("salesOrders", "salesOrderLines", bcfake.Navigation{EntitySet: "salesOrderLines", ForeignKey: "documentId", Collection: true})
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, bc.NewAPIPage[fakeOrder](client, "salesOrders")
+}
+
+func TestCreateWithLines(t *testing.T) {
+	srv, orders := newDeepInsertServer(t)
+	ctx := context.Background()
+
+	header := map[string]any{"number": "S-100"}
+	lines := []newOrderLine{{Description: "Bicycle", Quantity: 2}, {Description: "Helmet"}}
+
+	order, created, err := bc.CreateWithLines[createdOrderLine](ctx, orders, header, "salesOrderLines", lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if order.Number != "S-100" {
+		t.Errorf("wanted number S-100, got %s", order.Number)
+	}
+	if len(created) != 2 {
+		t.Fatalf("wanted 2 lines, got %d", len(created))
+	}
+	for _, line := range created {
+		if line.DocumentID != order.ID {
+			t.Errorf("wanted documentId %s, got %s", order.ID, line.DocumentID)
+		}
+	}
+	if created[0].Quantity != 2 {
+		t.Errorf("wanted quantity 2, got %d", created[0].Quantity)
+	}
+
+	if got := len(srv.Records("salesOrderLines")); got != 2 {
+		t.Errorf("wanted 2 stored lines, got %d", got)
+	}
+}
+
+func TestCreateWithLinesAllOrNothing(t *testing.T) {
+	srv, orders := newDeepInsertServer(t)
+	ctx := context.Background()
+
+	existing, err := srv.Insert("salesOrderLines", map[string]any{"description": "Existing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second line has a duplicate id
+	lines := []map[string]any{{"description": "New"}, {"id": existing, "description": "Duplicate"}}
+	_, _, err = bc.CreateWithLines[createdOrderLine](ctx, orders, map[string]any{"number": "S-200"}, "salesOrderLines", lines)
+	if !bc.IsConflict(err) {
+		t.Fatalf("wanted conflict, got %v", err)
+	}
+
+	if got := len(srv.Records("salesOrders")); got != 0 {
+		t.Errorf("wanted no orders, got %d", got)
+	}
+	if got := len(srv.Records("salesOrderLines")); got != 1 {
+		t.Errorf("wanted only the existing line, got %d", got)
+	}
+}
+
+func TestCreateWithLinesInvalidHeader(t *testing.T) {
+	_, orders := newDeepInsertServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		header     any
+		navigation string
+	}{
+		{"not an object", []string{"S-1"}, "salesOrderLines"},
+		{"has navigation", map[string]any{"salesOrderLines": nil}, "salesOrderLines"},
+		{"empty navigation", map[string]any{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := bc.CreateWithLines[createdOrderLine](ctx, orders, tt.header, tt.navigation, []newOrderLine{})
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package bc
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The error categories that an [APIError] can be matched against with errors.Is.
+// Use the predicates such as [IsNotFound] as a shorthand.
+var (
+	// ErrNotFound is matched when the record or resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched when the request conflicts with the current state
+	// of the record, for example a duplicate key or a changed record.
+	ErrConflict = errors.New("conflict")
+	// ErrPreconditionFailed is matched when the ETag sent in the If-Match header
+	// no longer matches the record. Re-read the record and retry.
+	// It also matches ErrConflict.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrThrottled is matched when BC rejected the request because of its
+	// service limits. It also matches ErrTransient.
+	ErrThrottled = errors.New("throttled")
+	// ErrValidationFailure is matched when BC business logic rejected the request,
+	// for example a field validation or an error dialog.
+	ErrValidationFailure = errors.New("validation failure")
+	// ErrAuthFailure is matched when the token is invalid or lacks permissions.
+	ErrAuthFailure = errors.New("auth failure")
+	// ErrTransient is matched by errors that may succeed if the request is retried.
+	ErrTransient = errors.New("transient")
+)
+
+// KnownErrorCodes maps the error codes returned by BC to an error category.
+// Codes that are not in the catalog are categorized by the status code.
+var KnownErrorCodes = map[string]error{
+	"BadRequest_NotFound":               ErrNotFound,
+	"BadRequest_ResourceNotFound":       ErrNotFound,
+	"BadRequest_MethodNotFound":         ErrNotFound,
+	"Internal_RecordNotFound":           ErrNotFound,
+	"Internal_EntityWithSameKeyExists":  ErrConflict,
+	"Request_EntityChanged":             ErrPreconditionFailed,
+	"Application_DialogException":       ErrValidationFailure,
+	"Application_EvaluateException":     ErrValidationFailure,
+	"BadRequest_InvalidToken":           ErrValidationFailure,
+	"Authentication_InvalidCredentials": ErrAuthFailure,
+	"Unauthorized":                      ErrAuthFailure,
+}
+
+// Category returns the error category of the APIError, or nil if it does
+// not belong to one. The code is looked up in [KnownErrorCodes] first and
+// then the status code is used.
+func (err APIError) Category() error {
+	if category, ok := KnownErrorCodes[err.Code]; ok {
+		return category
+	}
+
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusTooManyRequests:
+		return ErrThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuthFailure
+	case http.StatusBadRequest:
+		return ErrValidationFailure
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrTransient
+	}
+	return nil
+}
+
+// Is lets errors.Is match an APIError against the error categories.
+func (err APIError) Is(target error) bool {
+	category := err.Category()
+	if category == nil {
+		return false
+	}
+
+	switch {
+	case target == category:
+		return true
+	case target == ErrConflict:
+		return category == ErrPreconditionFailed
+	case target == ErrTransient:
+		return category == ErrThrottled
+	}
+	return false
+}
+
+// IsNotFound returns true if the error matches [ErrNotFound].
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsConflict returns true if the error matches [ErrConflict], which
+// includes [ErrPreconditionFailed].
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsPreconditionFailed returns true if the error matches [ErrPreconditionFailed].
+func IsPreconditionFailed(err error) bool {
+	return errors.Is(err, ErrPreconditionFailed)
+}
+
+// IsThrottled returns true if the error matches [ErrThrottled].
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
+// IsValidationFailure returns true if the error matches [ErrValidationFailure].
+func IsValidationFailure(err error) bool {
+	return errors.Is(err, ErrValidationFailure)
+}
+
+// IsAuthFailure returns true if the error matches [ErrAuthFailure].
+func IsAuthFailure(err error) bool {
+	return errors.Is(err, ErrAuthFailure)
+}
+
+// IsTransient returns true if the error matches [ErrTransient], which
+// includes [ErrThrottled].
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
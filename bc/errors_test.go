@@ -0,0 +1,56 @@
+package bc_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+)
+
+func TestAPIErrorCategories(t *testing.T) {
+	type testCase struct {
+		name       string
+		statusCode int
+		code       string
+		is         func(error) bool
+		want       bool
+	}
+
+	table := []testCase{
+		{"RecordNotFound", 404, "Internal_RecordNotFound", bc.IsNotFound, true},
+		{"BadRequestNotFound", 404, "BadRequest_NotFound", bc.IsNotFound, true},
+		{"UnknownCode404", 404, "Something_New", bc.IsNotFound, true},
+		{"EntityChanged", 409, "Request_EntityChanged", bc.IsPreconditionFailed, true},
+		{"EntityChangedConflict", 409, "Request_EntityChanged", bc.IsConflict, true},
+		{"SameKeyExists", 400, "Internal_EntityWithSameKeyExists", bc.IsConflict, true},
+		{"SameKeyExistsNotValidation", 400, "Internal_EntityWithSameKeyExists", bc.IsValidationFailure, false},
+		{"DialogException", 400, "Application_DialogException", bc.IsValidationFailure, true},
+		{"Unauthorized", 401, "Authentication_InvalidCredentials", bc.IsAuthFailure, true},
+		{"Throttled", 429, "", bc.IsThrottled, true},
+		{"ThrottledTransient", 429, "", bc.IsTransient, true},
+		{"Transient", 503, "", bc.IsTransient, true},
+		{"TransientNotThrottled", 503, "", bc.IsThrottled, false},
+		{"NotFoundNotTransient", 404, "Internal_RecordNotFound", bc.IsTransient, false},
+		{"ServerError", 500, "", bc.IsTransient, false},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			// Wrap it the same way the APIPage methods do
+			err := fmt.Errorf("error from BC API: %w", bc.APIError{StatusCode: test.statusCode, Code: test.code})
+
+			got := test.is(err)
+			if got != test.want {
+				t.Errorf("wanted %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestAPIErrorCategoryOtherError(t *testing.T) {
+	err := errors.New("network error")
+	if bc.IsNotFound(err) || bc.IsTransient(err) {
+		t.Error("plain error should not match any category")
+	}
+}
@@ -0,0 +1,22 @@
+package bc
+
+// ETag is the entity tag of a record, returned by BC in the "@odata.etag" field.
+// Send it with [APIPage.UpdateIfMatch] or [APIPage.DeleteIfMatch] (or RequestOptions.ETag)
+// so the request fails with [ErrPreconditionFailed] if the record was changed by someone else.
+type ETag string
+
+// ETagged can be embedded in a record struct to decode its "@odata.etag".
+type ETagged struct {
+	ETag ETag `json:"@odata.etag,omitempty"`
+}
+
+// RecordETag returns the ETag of the record.
+func (e ETagged) RecordETag() ETag {
+	return e.ETag
+}
+
+// ETagger is implemented by records that carry their ETag, for example
+// by embedding [ETagged].
+type ETagger interface {
+	RecordETag() ETag
+}
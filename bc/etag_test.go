@@ -0,0 +1,103 @@
+package bc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+	"github.com/google/uuid"
+)
+
+type fakeETagEntity struct {
+	bc.ETagged
+	ID     uuid.UUID `json:"id"`
+	Number string    `json:"number"`
+}
+
+func (f fakeETagEntity) Validate() error {
+	return nil
+}
+
+// newETagClient returns a client whose transport only accepts the If-Match currentETag.
+func newETagClient(t *testing.T, currentETag string, ifMatch *string) *bc.Client {
+	t.Helper()
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		*ifMatch = r.Header.Get("If-Match")
+
+		if *ifMatch != "*" && *ifMatch != currentETag {
+			body := bc.ErrorResponse{Error: bc.ErrorResponseError{
+				Code:    "Request_EntityChanged",
+				Message: "Another user has already changed the record.",
+			}}
+			return &http.Response{StatusCode: http.StatusPreconditionFailed, Body: bctest.NewRequestBody(body), Request: r}, nil
+		}
+
+		if r.Method == http.MethodDelete {
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+		}
+
+		body := map[string]any{"@odata.etag": `W/"2"`, "id": uuid.NewString(), "number": "1000"}
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestUpdateIfMatch(t *testing.T) {
+	var ifMatch string
+	client := newETagClient(t, `W/"1"`, &ifMatch)
+	page := bc.NewAPIPage[fakeETagEntity](client, "fakeEntities")
+	ctx := context.Background()
+
+	record, err := page.UpdateIfMatch(ctx, uuid.New(), `W/"1"`, nil, map[string]any{"number": "1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ifMatch != `W/"1"` {
+		t.Errorf("wanted If-Match %q, got %q", `W/"1"`, ifMatch)
+	}
+	if record.RecordETag() != `W/"2"` {
+		t.Errorf("wanted ETag %q, got %q", `W/"2"`, record.RecordETag())
+	}
+
+	_, err = page.UpdateIfMatch(ctx, uuid.New(), `W/"0"`, nil, map[string]any{"number": "1000"})
+	if !errors.Is(err, bc.ErrPreconditionFailed) {
+		t.Errorf("wanted ErrPreconditionFailed, got %v", err)
+	}
+
+	// Update still matches any version
+	if _, err := page.Update(ctx, uuid.New(), nil, map[string]any{"number": "1000"}); err != nil {
+		t.Fatal(err)
+	}
+	if ifMatch != "*" {
+		t.Errorf("wanted If-Match *, got %q", ifMatch)
+	}
+}
+
+func TestDeleteIfMatch(t *testing.T) {
+	var ifMatch string
+	client := newETagClient(t, `W/"1"`, &ifMatch)
+	page := bc.NewAPIPage[fakeETagEntity](client, "fakeEntities")
+	ctx := context.Background()
+
+	if err := page.DeleteIfMatch(ctx, uuid.New(), `W/"1"`); err != nil {
+		t.Fatal(err)
+	}
+
+	err := page.DeleteIfMatch(ctx, uuid.New(), `W/"0"`)
+	if !errors.Is(err, bc.ErrPreconditionFailed) {
+		t.Errorf("wanted ErrPreconditionFailed, got %v", err)
+	}
+
+	if err := page.DeleteIfMatch(ctx, uuid.New(), ""); err == nil {
+		t.Error("expected error for empty etag, got nil")
+	}
+}
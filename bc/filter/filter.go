@@ -0,0 +1,236 @@
+// Package filter builds OData $filter expressions for Business Central.
+// Values are formatted as OData literals, so strings are quoted and escaped,
+// GUIDs and dates are written unquoted and times are written in UTC.
+// The comparisons panic on a value that [Format] does not support, e.g. a struct.
+//
+//	f := filter.And(
+//		filter.Eq("customerName", "O'Brien"),
+//		filter.Ge("orderDate", bc.DateOf(time.Now())),
+//	)
+//	opts := bc.ListOptions{Filter: f.String()}
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// kind is used to decide when an expression needs parentheses.
+type kind int
+
+const (
+	kindEmpty kind = iota
+	kindAtom
+	kindAnd
+	kindOr
+	kindRaw
+)
+
+// Expr is a filter expression. The zero value is an empty filter.
+type Expr struct {
+	s    string
+	kind kind
+}
+
+// String returns the expression for the $filter query param.
+func (e Expr) String() string {
+	return e.s
+}
+
+// IsZero returns true if it is an empty filter.
+func (e Expr) IsZero() bool {
+	return e.kind == kindEmpty
+}
+
+// Literal is implemented by types that format themselves as an OData literal,
+// such as bc.Date.
+type Literal interface {
+	ODataLiteral() string
+}
+
+// Raw wraps an existing filter string. It is put in parentheses when
+// combined with other expressions. An empty string is an empty filter.
+func Raw(s string) Expr {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Expr{}
+	}
+	return Expr{s: s, kind: kindRaw}
+}
+
+// Eq is "field eq value".
+func Eq(field string, value any) Expr {
+	return compare(field, "eq", value)
+}
+
+// Ne is "field ne value".
+func Ne(field string, value any) Expr {
+	return compare(field, "ne", value)
+}
+
+// Gt is "field gt value".
+func Gt(field string, value any) Expr {
+	return compare(field, "gt", value)
+}
+
+// Ge is "field ge value".
+func Ge(field string, value any) Expr {
+	return compare(field, "ge", value)
+}
+
+// Lt is "field lt value".
+func Lt(field string, value any) Expr {
+	return compare(field, "lt", value)
+}
+
+// Le is "field le value".
+func Le(field string, value any) Expr {
+	return compare(field, "le", value)
+}
+
+// compare panics on an unsupported value, as that is a programming error
+// like passing a struct, rather than a bad value at runtime.
+func compare(field, op string, value any) Expr {
+	literal, err := Format(value)
+	if err != nil {
+		panic(fmt.Sprintf("filter %s %s: %s", field, op, err))
+	}
+	return Expr{s: fmt.Sprintf("%s %s %s", field, op, literal), kind: kindAtom}
+}
+
+// StartsWith is "startswith(field,'value')".
+func StartsWith(field, value string) Expr {
+	return Expr{s: fmt.Sprintf("startswith(%s,%s)", field, quote(value)), kind: kindAtom}
+}
+
+// EndsWith is "endswith(field,'value')".
+func EndsWith(field, value string) Expr {
+	return Expr{s: fmt.Sprintf("endswith(%s,%s)", field, quote(value)), kind: kindAtom}
+}
+
+// Contains is "contains(field,'value')".
+func Contains(field, value string) Expr {
+	return Expr{s: fmt.Sprintf("contains(%s,%s)", field, quote(value)), kind: kindAtom}
+}
+
+// In matches any of the values. It is written as "field eq a or field eq b",
+// which every BC version supports. No values is an empty filter.
+func In[V any](field string, values ...V) Expr {
+	exprs := make([]Expr, len(values))
+	for i, v := range values {
+		exprs[i] = Eq(field, v)
+	}
+	return Or(exprs...)
+}
+
+// And combines the expressions with "and". Empty expressions are skipped.
+func And(exprs ...Expr) Expr {
+	return join(kindAnd, " and ", exprs, func(e Expr) bool {
+		return e.kind == kindOr || e.kind == kindRaw
+	})
+}
+
+// Or combines the expressions with "or". Empty expressions are skipped.
+func Or(exprs ...Expr) Expr {
+	return join(kindOr, " or ", exprs, func(e Expr) bool {
+		return e.kind == kindRaw
+	})
+}
+
+// join combines the non-empty expressions, putting parentheses around the ones
+// that need them. A single expression is returned as is.
+func join(k kind, sep string, exprs []Expr, needsParens func(Expr) bool) Expr {
+	var nonEmpty []Expr
+	for _, e := range exprs {
+		if !e.IsZero() {
+			nonEmpty = append(nonEmpty, e)
+		}
+	}
+
+	switch len(nonEmpty) {
+	case 0:
+		return Expr{}
+	case 1:
+		return nonEmpty[0]
+	}
+
+	parts := make([]string, len(nonEmpty))
+	for i, e := range nonEmpty {
+		parts[i] = e.s
+		if needsParens(e) {
+			parts[i] = "(" + e.s + ")"
+		}
+	}
+
+	return Expr{s: strings.Join(parts, sep), kind: k}
+}
+
+// Not negates the expression. Not of an empty filter is an empty filter.
+func Not(e Expr) Expr {
+	if e.IsZero() {
+		return e
+	}
+	return Expr{s: fmt.Sprintf("not (%s)", e.s), kind: kindAtom}
+}
+
+// Format formats the value as an OData literal. Strings, enums and other
+// string types are quoted with single quotes escaped. GUIDs, numbers, booleans
+// and Literals are unquoted. A time.Time is written in UTC. Pointers are
+// formatted as the value they point to and a nil pointer is "null".
+// It returns an error if the value is of an unsupported type.
+func Format(value any) (string, error) {
+	// Dereference first so *uuid.UUID and *time.Time match their own cases
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "null", nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "null", nil
+	}
+
+	switch v := rv.Interface().(type) {
+	case Literal:
+		return v.ODataLiteral(), nil
+	case uuid.UUID:
+		return v.String(), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case fmt.Stringer:
+		// Enums
+		return quote(v.String()), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return quote(rv.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %T", value)
+}
+
+// quote puts the string in single quotes and escapes single quotes by doubling them.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
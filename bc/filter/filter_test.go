@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type status string
+
+type testDate struct{}
+
+func (testDate) ODataLiteral() string { return "2024-02-20" }
+
+func TestFormat(t *testing.T) {
+	id := uuid.MustParse("7d5d2cb1-4f2b-4bd4-9bb5-2c0a3c6c2f6a")
+	ts := time.Date(2024, 2, 20, 18, 30, 0, 0, time.FixedZone("CST", -6*3600))
+	date := testDate{}
+	name := "O'Brien"
+	quantity := 5
+
+	type testCase struct {
+		name  string
+		value any
+		want  string
+	}
+
+	table := []testCase{
+		{"String", "ACME", "'ACME'"},
+		{"StringQuote", "O'Brien", "'O''Brien'"},
+		{"Enum", status("Open"), "'Open'"},
+		{"UUID", id, "7d5d2cb1-4f2b-4bd4-9bb5-2c0a3c6c2f6a"},
+		{"Literal", testDate{}, "2024-02-20"},
+		{"Time", ts, "2024-02-21T00:30:00Z"},
+		{"Int", 42, "42"},
+		{"Float", 12.5, "12.5"},
+		{"Bool", true, "true"},
+		{"Nil", nil, "null"},
+		{"PointerUUID", &id, "7d5d2cb1-4f2b-4bd4-9bb5-2c0a3c6c2f6a"},
+		{"PointerTime", &ts, "2024-02-21T00:30:00Z"},
+		{"PointerLiteral", &date, "2024-02-20"},
+		{"PointerString", &name, "'O''Brien'"},
+		{"PointerInt", &quantity, "5"},
+		{"NilUUID", (*uuid.UUID)(nil), "null"},
+		{"NilTime", (*time.Time)(nil), "null"},
+		{"NilLiteral", (*testDate)(nil), "null"},
+		{"NilString", (*string)(nil), "null"},
+		{"NilInt", (*int)(nil), "null"},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Format(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("wanted %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatUnsupported(t *testing.T) {
+	if got, err := Format(struct{}{}); err == nil {
+		t.Errorf("expected error for struct, got %s", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Eq to panic for struct")
+		}
+	}()
+	Eq("a", struct{}{})
+}
+
+func TestExpr(t *testing.T) {
+	type testCase struct {
+		name string
+		expr Expr
+		want string
+	}
+
+	table := []testCase{
+		{"Eq", Eq("number", "1000"), "number eq '1000'"},
+		{"And", And(Eq("a", 1), Gt("b", 2)), "a eq 1 and b gt 2"},
+		{"AndOr", And(Eq("a", 1), Or(Eq("b", 2), Eq("c", 3))), "a eq 1 and (b eq 2 or c eq 3)"},
+		{"OrAnd", Or(And(Eq("a", 1), Eq("b", 2)), Eq("c", 3)), "a eq 1 and b eq 2 or c eq 3"},
+		{"AndRaw", And(Raw("a eq 1 or b eq 2"), Eq("c", 3)), "(a eq 1 or b eq 2) and c eq 3"},
+		{"AndSkipEmpty", And(Raw(""), Eq("c", 3), Expr{}), "c eq 3"},
+		{"AndEmpty", And(), ""},
+		{"Not", Not(StartsWith("name", "A")), "not (startswith(name,'A'))"},
+		{"Contains", Contains("name", "it's"), "contains(name,'it''s')"},
+		{"EndsWith", EndsWith("name", "Inc"), "endswith(name,'Inc')"},
+		{"In", In("status", status("Open"), status("Released")), "status eq 'Open' or status eq 'Released'"},
+		{"InAnd", And(Eq("a", 1), In("b", 2, 3)), "a eq 1 and (b eq 2 or b eq 3)"},
+		{"InEmpty", In[string]("status"), ""},
+		{"Compare", And(Ne("a", 1), Ge("b", 2), Lt("c", 3), Le("d", 4)), "a ne 1 and b ge 2 and c lt 3 and d le 4"},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.expr.String()
+			if got != test.want {
+				t.Errorf("wanted %q, got %q", test.want, got)
+			}
+		})
+	}
+}
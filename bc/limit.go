@@ -0,0 +1,158 @@
+package bc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that limits the rate of requests.
+// It is safe for concurrent use. Share one RateLimiter between all
+// Clients that target the same environment with [WithRateLimit] so
+// they count against the same limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a [RateLimiter] that allows requestsPerSecond
+// with bursts of up to burst requests. It panics if either is not positive.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if requestsPerSecond <= 0 || burst <= 0 {
+		panic("create rate limiter: requestsPerSecond and burst must be positive")
+	}
+
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	// Refill the bucket for the time since the last call
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// Reserve a token, going negative if there are none left
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		// Give back the reserved token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ConcurrencyLimiter limits the number of requests in flight.
+// It is safe for concurrent use. Share one ConcurrencyLimiter between all
+// Clients that target the same environment with [WithMaxConcurrency].
+type ConcurrencyLimiter struct {
+	sem chan struct{}
+}
+
+// NewConcurrencyLimiter creates a [ConcurrencyLimiter] that allows up to
+// max requests at the same time. It panics if max is not positive.
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	if max <= 0 {
+		panic("create concurrency limiter: max must be positive")
+	}
+
+	return &ConcurrencyLimiter{sem: make(chan struct{}, max)}
+}
+
+// Acquire blocks until a request is allowed or the context is done.
+// Each successful Acquire must be followed by a Release.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot taken by Acquire.
+func (l *ConcurrencyLimiter) Release() {
+	<-l.sem
+}
+
+// releaseBody releases the concurrency slot once the response body is closed,
+// so a request counts as in flight until it has been read.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// send waits for the rate and concurrency limiters, if any, and sends
+// the request with the baseClient.
+func (c *Client) send(r *http.Request) (*http.Response, error) {
+	if c.rateLimiter == nil && c.concurrencyLimiter == nil {
+		return c.baseClient.Do(r)
+	}
+
+	ctx := r.Context()
+	start := time.Now()
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("wait for rate limit: %w", err)
+		}
+	}
+	rateWait := time.Since(start)
+
+	if c.concurrencyLimiter != nil {
+		if err := c.concurrencyLimiter.Acquire(ctx); err != nil {
+			return nil, fmt.Errorf("wait for concurrency limit: %w", err)
+		}
+	}
+	concurrencyWait := time.Since(start) - rateWait
+
+	c.logger.Debug("Request limits acquired.", "url", r.URL.String(), "rateWait", rateWait, "concurrencyWait", concurrencyWait)
+
+	res, err := c.baseClient.Do(r)
+
+	if c.concurrencyLimiter == nil {
+		return res, err
+	}
+
+	if err != nil {
+		c.concurrencyLimiter.Release()
+		return res, err
+	}
+
+	res.Body = &releaseBody{ReadCloser: res.Body, release: c.concurrencyLimiter.Release}
+	return res, nil
+}
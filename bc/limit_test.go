@@ -0,0 +1,94 @@
+package bc_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+	"github.com/google/uuid"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := bc.NewRateLimiter(100, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 5 {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// First is free, the next 4 are 10ms apart
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("wanted at least 35ms, got %s", elapsed)
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	limiter := bc.NewRateLimiter(0.1, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("first wait should not block: %s", err)
+	}
+	if err := limiter.Wait(ctx); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestMaxConcurrencyShared(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(map[string]any{"ID": "1"}), Request: r}, nil
+	})
+
+	limiter := bc.NewConcurrencyLimiter(2)
+	httpClient := &http.Client{Transport: transport}
+
+	// Two clients for different endpoints share the limiter
+	var pages []*bc.APIPage[fakeEntity]
+	for _, endpoint := range []string{"v2.0", "publisher/group/1.0"} {
+		config := fakeConfig
+		config.APIEndpoint = endpoint
+
+		client, err := bc.NewClient(config, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(httpClient), bc.WithMaxConcurrency(limiter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, bc.NewAPIPage[fakeEntity](client, "fakeEntities"))
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			if _, err := pages[i%2].Get(context.Background(), uuid.New(), bc.GetOptions{}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("wanted at most 2 requests in flight, got %d", got)
+	}
+}
@@ -0,0 +1,128 @@
+package bc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// The paths of common media streams, relative to the record.
+const (
+	// MediaPictureContent is the picture of items, customers, vendors,
+	// employees and contacts, e.g. "items({id})/picture/pictureContent".
+	MediaPictureContent = "picture/pictureContent"
+	// MediaAttachmentContent is the content of an attachment,
+	// e.g. "attachments({id})/attachmentContent".
+	MediaAttachmentContent = "attachmentContent"
+	// MediaPDFDocumentContent is the PDF of a document,
+	// e.g. "salesInvoices({id})/pdfDocument/pdfDocumentContent".
+	MediaPDFDocumentContent = "pdfDocument/pdfDocumentContent"
+)
+
+// DownloadMedia makes a GET request for the media stream at the path of the
+// record, e.g. [MediaPictureContent]. The content is streamed and not buffered,
+// so the caller must close the returned io.ReadCloser.
+func (a *APIPage[T]) DownloadMedia(ctx context.Context, id uuid.UUID, path string) (io.ReadCloser, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("download media: id is empty")
+	}
+	if path == "" {
+		return nil, errors.New("download media: path is empty")
+	}
+
+	opts := RequestOptions{
+		Method:        http.MethodGet,
+		EntitySetName: a.entitySetName,
+		RecordID:      id,
+		Path:          path,
+		Header:        http.Header{"Accept": {"*/*"}},
+	}
+	req, err := a.client.NewRequest(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	a.client.logger.Debug("Sending request...", "url", req.URL.String(), "method", req.Method)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed during request: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		defer res.Body.Close()
+
+		err := decodeErrorResponse(res)
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			a.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return nil, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return res.Body, nil
+}
+
+// UploadMedia makes a PATCH request that replaces the media stream at the path
+// of the record with the content of r, which is streamed and not buffered.
+// The contentType defaults to "application/octet-stream". The etag is sent in
+// the If-Match header and defaults to "*", which matches any version.
+// A stream that cannot be replayed is not retried.
+func (a *APIPage[T]) UploadMedia(ctx context.Context, id uuid.UUID, path string, etag ETag, contentType string, r io.Reader) error {
+	if id == uuid.Nil {
+		return errors.New("upload media: id is empty")
+	}
+	if path == "" {
+		return errors.New("upload media: path is empty")
+	}
+	if r == nil {
+		return errors.New("upload media: reader is nil")
+	}
+
+	opts := RequestOptions{
+		Method:        http.MethodPatch,
+		EntitySetName: a.entitySetName,
+		RecordID:      id,
+		Path:          path,
+		Body:          r,
+		ETag:          etag,
+	}
+	if contentType != "" {
+		opts.Header = http.Header{"Content-Type": {contentType}}
+	}
+
+	req, err := a.client.NewRequest(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	a.client.logger.Debug("Sending request...", "url", req.URL.String(), "method", req.Method)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed during request: %w", err)
+	}
+
+	// Expects a 204 No Content
+	err = DecodeNoContent(res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			a.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		a.client.logger.Debug("Failed to decode response.", "error", err)
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	a.client.logger.Debug("Successfully uploaded media.", "id", id, "path", path)
+
+	return nil
+}
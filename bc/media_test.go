@@ -0,0 +1,137 @@
+package bc_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/internal/bctest"
+	"github.com/google/uuid"
+)
+
+// onlyReader hides the concrete type so the body cannot be replayed.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestMedia(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	id, err := srv.Insert("items", fakeETagEntity{Number: "1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddMedia("items", bc.MediaPictureContent)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := bc.NewAPIPage[fakeETagEntity](client, "items")
+	ctx := context.Background()
+
+	item, err := page.Get(ctx, id, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	picture := bytes.Repeat([]byte{0xff, 0xd8}, 1024)
+	err = page.UploadMedia(ctx, id, bc.MediaPictureContent, item.ETag, "image/jpeg", onlyReader{bytes.NewReader(picture)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, contentType, err := srv.Media("items", id, bc.MediaPictureContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, picture) {
+		t.Errorf("wanted %d bytes stored, got %d", len(picture), len(data))
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("wanted Content-Type image/jpeg, got %s", contentType)
+	}
+
+	// The upload changed the ETag
+	err = page.UploadMedia(ctx, id, bc.MediaPictureContent, item.ETag, "", strings.NewReader("x"))
+	if !bc.IsPreconditionFailed(err) {
+		t.Errorf("wanted precondition failed, got %v", err)
+	}
+
+	rc, err := page.DownloadMedia(ctx, id, bc.MediaPictureContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, picture) {
+		t.Errorf("wanted %d bytes downloaded, got %d", len(picture), len(got))
+	}
+
+	_, err = page.DownloadMedia(ctx, uuid.New(), bc.MediaPictureContent)
+	if !bc.IsNotFound(err) {
+		t.Errorf("wanted not found, got %v", err)
+	}
+}
+
+func TestUploadMediaHeaders(t *testing.T) {
+	var contentType, ifMatch string
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		contentType = r.Header.Get("Content-Type")
+		ifMatch = r.Header.Get("If-Match")
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := bc.NewAPIPage[fakeEntity](client, "attachments")
+
+	if err := page.UploadMedia(context.Background(), uuid.New(), bc.MediaAttachmentContent, "", "", strings.NewReader("content")); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != bc.ContentTypeOctetStream {
+		t.Errorf("wanted Content-Type %s, got %s", bc.ContentTypeOctetStream, contentType)
+	}
+	if ifMatch != "*" {
+		t.Errorf("wanted If-Match *, got %s", ifMatch)
+	}
+}
+
+func TestUploadMediaNotRetried(t *testing.T) {
+	attempts := 0
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: bctest.NewRequestBody(bc.ErrorResponse{}), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig,
+		bc.WithAuthClient(fakeTokenGetter{}),
+		bc.WithHTTPClient(&http.Client{Transport: transport}),
+		bc.WithRetry(bc.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := bc.NewAPIPage[fakeEntity](client, "items")
+
+	err = page.UploadMedia(context.Background(), uuid.New(), bc.MediaPictureContent, `W/"1"`, "image/png", onlyReader{strings.NewReader("png")})
+	if !bc.IsTransient(err) {
+		t.Errorf("wanted transient error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("wanted 1 attempt for a stream, got %d", attempts)
+	}
+}
@@ -0,0 +1,41 @@
+package bc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ContentTypeXML is the "Accept" header value for the $metadata document.
+const ContentTypeXML = "application/xml"
+
+// Metadata downloads the $metadata CSDL document of the API endpoint.
+// It describes the entity types, enums and entity sets of the endpoint and
+// is the input for the bcgen code generator.
+func (c *Client) Metadata(ctx context.Context) ([]byte, error) {
+	metadataURL := c.apiRootURL()
+	metadataURL.Path += "/$metadata"
+
+	req, err := c.newRequestURL(ctx, http.MethodGet, metadataURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+	req.Header.Set("Accept", ContentTypeXML)
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed during request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("error from BC API: %w", decodeErrorResponse(res))
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Response.Body: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,249 @@
+package bc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// listAll sends the request for the first page and then follows the
+// @odata.nextLink of each page until there are no more pages or
+// maxRecords is reached. Every page is decoded and validated with Decode.
+// A maxRecords of zero means there is no limit.
+func listAll[T any](c *Client, req *http.Request, maxRecords int) ([]T, error) {
+	var values []T
+
+	for {
+		page, err := getPage[T](c, req)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, page.Value...)
+
+		if maxRecords > 0 && len(values) >= maxRecords {
+			return values[:maxRecords], nil
+		}
+
+		if page.NextLink == "" {
+			return values, nil
+		}
+
+		c.logger.Debug("Following next link.", "url", page.NextLink, "records", len(values))
+
+		req, err = c.newNextLinkRequest(req.Context(), page.NextLink, req.Header.Get("Prefer"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Request: %w", err)
+		}
+	}
+}
+
+// getPage sends the request and decodes a single page of a list.
+func getPage[T any](c *Client, req *http.Request) (APIListResponse[T], error) {
+	res, err := c.Do(req)
+	if err != nil {
+		return APIListResponse[T]{}, fmt.Errorf("failed during request: %w", err)
+	}
+
+	page, err := Decode[APIListResponse[T]](res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			c.logger.Debug("API server returned error response.", "error", srvErr)
+			return page, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		c.logger.Debug("Unable to decode response.", "error", err)
+		return page, fmt.Errorf("decode response: %w", err)
+	}
+
+	return page, nil
+}
+
+// newNextLinkRequest creates the GET request for the next page of a list.
+// The next link must point to the same host as the Client so the token
+// is never sent anywhere else.
+func (c *Client) newNextLinkRequest(ctx context.Context, nextLink string, prefer string) (*http.Request, error) {
+	u, err := url.Parse(nextLink)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next link %q: %w", nextLink, err)
+	}
+
+	// Resolve relative links against the base URL
+	u = c.baseURL.ResolveReference(u)
+
+	if u.Scheme != c.baseURL.Scheme || u.Host != c.baseURL.Host {
+		return nil, fmt.Errorf("next link %q does not match host %q", nextLink, c.baseURL.Host)
+	}
+
+	req, err := c.newRequestURL(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Data-Access-Intent", DataAccessReadOnly)
+
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	return req, nil
+}
+
+// streamAll returns an iterator that requests the first page with opts and
+// follows the @odata.nextLink of each page. The value array of each page is
+// decoded one record at a time so only a single record is held in memory.
+// Stopping the iteration cancels any request that is still in flight.
+func streamAll[T any](ctx context.Context, c *Client, opts RequestOptions, maxRecords int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		req, err := c.NewRequest(ctx, opts)
+		if err != nil {
+			yield(zero, fmt.Errorf("failed to create Request: %w", err))
+			return
+		}
+
+		count := 0
+		for {
+			res, err := c.Do(req)
+			if err != nil {
+				yield(zero, fmt.Errorf("failed during request: %w", err))
+				return
+			}
+
+			if res.StatusCode < 200 || res.StatusCode >= 300 {
+				err := decodeErrorResponse(res)
+				res.Body.Close()
+				var srvErr APIError
+				if errors.As(err, &srvErr) {
+					c.logger.Debug("API server returned error response.", "error", srvErr)
+					yield(zero, fmt.Errorf("error from BC API: %w", srvErr))
+					return
+				}
+				yield(zero, fmt.Errorf("decode response: %w", err))
+				return
+			}
+
+			nextLink, more, err := streamPage(res.Body, func(v T) bool {
+				count++
+				if !yield(v, nil) {
+					return false
+				}
+				return maxRecords <= 0 || count < maxRecords
+			})
+			res.Body.Close()
+
+			if err != nil {
+				c.logger.Debug("Unable to decode response.", "error", err)
+				yield(zero, fmt.Errorf("decode response: %w", err))
+				return
+			}
+
+			if !more || nextLink == "" {
+				return
+			}
+
+			c.logger.Debug("Following next link.", "url", nextLink, "records", count)
+
+			req, err = c.newNextLinkRequest(ctx, nextLink, req.Header.Get("Prefer"))
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to create Request: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// streamPage decodes a list response body and calls fn with each record
+// of the value array as it is read. It returns the @odata.nextLink and
+// false if fn returned false.
+func streamPage[T any](body io.Reader, fn func(T) bool) (string, bool, error) {
+	var nextLink string
+
+	d := json.NewDecoder(body)
+
+	if err := expectDelim(d, '{'); err != nil {
+		return "", false, err
+	}
+
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return "", false, err
+		}
+
+		switch tok {
+		case "value":
+			if err := expectDelim(d, '['); err != nil {
+				return "", false, err
+			}
+
+			for d.More() {
+				var v T
+				if err := d.Decode(&v); err != nil {
+					return "", false, fmt.Errorf("could not decode %T: %w", v, err)
+				}
+
+				if err := validateRecord(v); err != nil {
+					return "", false, fmt.Errorf("failed validation of %T: %w", v, err)
+				}
+
+				if !fn(v) {
+					return "", false, nil
+				}
+			}
+
+			if err := expectDelim(d, ']'); err != nil {
+				return "", false, err
+			}
+		case "@odata.nextLink":
+			if err := d.Decode(&nextLink); err != nil {
+				return "", false, fmt.Errorf("could not decode next link: %w", err)
+			}
+		default:
+			// Skip any other field
+			var skip json.RawMessage
+			if err := d.Decode(&skip); err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	return nextLink, true, nil
+}
+
+// expectDelim reads the next token and returns an error if it is not delim.
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("expected %q, got %v", delim, tok)
+	}
+	return nil
+}
+
+// validateRecord calls Validate if v is a Validator, otherwise it
+// validates the struct tags of a struct.
+func validateRecord(v any) error {
+	if validator, ok := v.(Validator); ok {
+		return validator.Validate()
+	}
+
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() == reflect.Struct {
+		return ValidateStruct(v)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package bc_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+)
+
+// newPagingClient returns a client whose transport serves the number of pages,
+// each with 2 records, linked together with @odata.nextLink.
+func newPagingClient(t *testing.T, pages int, requests *[]*http.Request) *bc.Client {
+	t.Helper()
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		*requests = append(*requests, r)
+		page := len(*requests)
+
+		body := map[string]any{
+			"value": []map[string]any{
+				{"ID": fmt.Sprintf("%d-1", page)},
+				{"ID": fmt.Sprintf("%d-2", page)},
+			},
+		}
+		if page < pages {
+			next := *r.URL
+			next.RawQuery = fmt.Sprintf("$skiptoken=%d", page)
+			body["@odata.nextLink"] = next.String()
+		}
+
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestAPIPageListPaging(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 3, &requests)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+	records, err := page.List(context.Background(), bc.ListOptions{MaxPageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 3 {
+		t.Errorf("wanted 3 requests, got %d", len(requests))
+	}
+
+	if len(records) != 6 {
+		t.Errorf("wanted 6 records, got %d", len(records))
+	}
+
+	for i, r := range requests {
+		want := "odata.maxpagesize=2"
+		got := r.Header.Get("Prefer")
+		if want != got {
+			t.Errorf("request %d: wanted Prefer %q, got %q", i, want, got)
+		}
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("request %d: missing Authorization header", i)
+		}
+	}
+}
+
+func TestAPIPageListMaxRecords(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 5, &requests)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+	records, err := page.List(context.Background(), bc.ListOptions{MaxRecords: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("wanted 2 requests, got %d", len(requests))
+	}
+
+	if len(records) != 3 {
+		t.Errorf("wanted 3 records, got %d", len(records))
+	}
+}
+
+func TestAPIQueryListPaging(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 2, &requests)
+
+	query := bc.NewAPIQuery[fakeEntity](client, "fakeQuery")
+	records, err := query.List(context.Background(), bc.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 4 {
+		t.Errorf("wanted 4 records, got %d", len(records))
+	}
+}
+
+func TestListNextLinkOtherHost(t *testing.T) {
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := map[string]any{
+			"value":           []map[string]any{{"ID": "1"}},
+			"@odata.nextLink": "https://example.com/fakeEntities?$skiptoken=1",
+		}
+		return &http.Response{StatusCode: 200, Body: bctest.NewRequestBody(body), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+	if _, err := page.List(context.Background(), bc.ListOptions{}); err == nil {
+		t.Fatal("expected error for next link to another host, got nil")
+	}
+}
+
+func TestAPIPageAll(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 3, &requests)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+
+	count := 0
+	for record, err := range page.All(context.Background(), bc.ListOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.ID == "" {
+			t.Errorf("record %d: ID is empty", count)
+		}
+		count++
+	}
+
+	if count != 6 {
+		t.Errorf("wanted 6 records, got %d", count)
+	}
+}
+
+func TestAPIPageAllBreak(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 3, &requests)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+
+	for _, err := range page.All(context.Background(), bc.ListOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+
+	if len(requests) != 1 {
+		t.Errorf("wanted 1 request, got %d", len(requests))
+	}
+
+	if requests[0].Context().Err() == nil {
+		t.Error("expected request context to be canceled after break")
+	}
+}
+
+func TestAPIQueryAllMaxRecords(t *testing.T) {
+	var requests []*http.Request
+	client := newPagingClient(t, 3, &requests)
+
+	query := bc.NewAPIQuery[fakeEntity](client, "fakeQuery")
+
+	count := 0
+	for _, err := range query.All(context.Background(), bc.ListOptions{MaxRecords: 4}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+
+	if count != 4 {
+		t.Errorf("wanted 4 records, got %d", count)
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("wanted 2 requests, got %d", len(requests))
+	}
+}
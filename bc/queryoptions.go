@@ -2,9 +2,12 @@ package bc
 
 import (
 	"fmt"
+	"net/http"
 	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/erlorenz/bc-go/bc/filter"
 )
 
 // GetOptions build the QueryParams.
@@ -25,36 +28,24 @@ func (q *GetOptions) BuildQueryParams(baseExpand []string) QueryParams {
 
 // ListOptions build the QueryParams.
 type ListOptions struct {
-	Filter  string   // The filter expression. Combined with the BaseFilter.
+	Filter  string   // The filter expression, e.g. from the filter package. Combined with the BaseFilter.
 	Expand  []string // The expandable fields. Added to the BaseExpand.
 	OrderBy []string // The fields to order by, e.g. "field1 desc" or "field1". Ascending is default.
 	Select  []string // The fields to return.
-	Skip    int      // The number of records to skip. Do not use for pagination.
-	Top     int      // The number of records to return. Do not use for pagination.
+	Skip    int      // The number of records to skip. Do not use for pagination, List follows next links.
+	Top     int      // The number of records to return. Do not use for pagination, use MaxRecords.
+	Apply   string   // The $apply transformations, e.g. "groupby((customerNumber),aggregate(amount with sum as total))".
+
+	MaxPageSize int  // The preferred number of records per page. Sent as "Prefer: odata.maxpagesize".
+	MaxRecords  int  // The maximum number of records to return across all pages. Zero means no limit.
+	Count       bool // Request the number of matching records, ignoring Top and Skip. See APIPage.ListPage.
 }
 
 // BuildQueryParams combines the base filter/expand with the provided ListQueryOptions to return QueryParams
 // for the request.
 func (q *ListOptions) BuildQueryParams(baseFilter string, baseExpand []string) QueryParams {
-	// Filter should be in format "<baseFilter> and (<extrafilter>)"
-	// Only supports adding to the base filter --don't use base filter if you need an "or"
-	filterStrings := []string{}
-
-	// Add the baseFilter
-	if baseFilter != "" {
-		filterStrings = append(filterStrings, baseFilter)
-	}
-
-	// Add the filter and surround with "()" if there is a baseFilter
-	if q.Filter != "" {
-		filter := q.Filter
-		if baseFilter != "" {
-			filter = fmt.Sprintf("(%s)", filter)
-		}
-		filterStrings = append(filterStrings, filter)
-	}
-
-	filter := strings.Join(filterStrings, " and ")
+	// Filter is in format "(<baseFilter>) and (<extrafilter>)"
+	filterString := filter.And(filter.Raw(baseFilter), filter.Raw(q.Filter)).String()
 
 	// Expand should be comma separated
 	expandSlice := slices.Concat(baseExpand, q.Expand)
@@ -63,8 +54,8 @@ func (q *ListOptions) BuildQueryParams(baseFilter string, baseExpand []string) Q
 	qp := QueryParams{}
 
 	// Set $filter if exists
-	if filter != "" {
-		qp["$filter"] = filter
+	if filterString != "" {
+		qp["$filter"] = filterString
 	}
 
 	// Set $expand if exists
@@ -76,12 +67,38 @@ func (q *ListOptions) BuildQueryParams(baseFilter string, baseExpand []string) Q
 		qp["$orderby"] = strings.Join(q.OrderBy, ",")
 	}
 
+	if len(q.Select) > 0 {
+		qp["$select"] = strings.Join(q.Select, ",")
+	}
+
 	// Set $top if exists
 	if q.Top != 0 {
 		qp["$top"] = strconv.Itoa(q.Top)
+	}
 
+	if q.Skip > 0 {
 		qp["$skip"] = strconv.Itoa(q.Skip)
 	}
 
+	if q.Apply != "" {
+		qp["$apply"] = q.Apply
+	}
+
+	if q.Count {
+		qp["$count"] = "true"
+	}
+
 	return qp
 }
+
+// BuildHeader returns the extra request headers for the ListOptions.
+// It returns nil if there are none.
+func (q *ListOptions) BuildHeader() http.Header {
+	if q.MaxPageSize <= 0 {
+		return nil
+	}
+
+	h := http.Header{}
+	h.Set("Prefer", fmt.Sprintf("odata.maxpagesize=%d", q.MaxPageSize))
+	return h
+}
@@ -30,8 +30,8 @@ func TestBuildQueryParams_List(t *testing.T) {
 		t.Errorf(`wrong top: expected "5", got "%s"`, qp["$top"])
 	}
 
-	if qp["$skip"] != "0" {
-		t.Errorf(`wrong skip: expected "0", got "%s"`, qp["$skip"])
+	if _, ok := qp["$skip"]; ok {
+		t.Errorf(`expected no skip, got "%s"`, qp["$skip"])
 	}
 
 	if qp["$orderby"] != "number asc" {
@@ -72,7 +72,8 @@ func TestBuildQueryParamsWithBase_List(t *testing.T) {
 	baseFilter := "documentType eq 'Order'"
 	baseExpand := []string{"dimensionSetLines"}
 
-	expectedFilter := "documentType eq 'Order' and (number eq 'XXXX')"
+	// Both are in parentheses so a base filter with "or" keeps its meaning
+	expectedFilter := "(documentType eq 'Order') and (number eq 'XXXX')"
 	expectedExpand := "dimensionSetLines,salesLines,customer"
 
 	qp := opts.BuildQueryParams(baseFilter, baseExpand)
@@ -114,3 +115,56 @@ func TestBuildQueryParamsWithBase_Get(t *testing.T) {
 	}
 
 }
+
+func TestBuildQueryParamsCount_List(t *testing.T) {
+
+	opts := ListOptions{Count: true}
+	qp := opts.BuildQueryParams("", nil)
+
+	if qp["$count"] != "true" {
+		t.Errorf(`wrong count: expected "true", got "%s"`, qp["$count"])
+	}
+
+	opts.Count = false
+	qp = opts.BuildQueryParams("", nil)
+
+	if _, ok := qp["$count"]; ok {
+		t.Errorf(`expected no count, got "%s"`, qp["$count"])
+	}
+
+}
+
+func TestBuildQueryParamsSelectSkipApply_List(t *testing.T) {
+
+	opts := ListOptions{
+		Select: []string{"id", "number"},
+		Skip:   10,
+		Apply:  "groupby((customerNumber),aggregate(amount with sum as total))",
+	}
+	qp := opts.BuildQueryParams("", nil)
+
+	if qp["$select"] != "id,number" {
+		t.Errorf(`wrong select: expected "id,number", got "%s"`, qp["$select"])
+	}
+
+	if qp["$skip"] != "10" {
+		t.Errorf(`wrong skip: expected "10", got "%s"`, qp["$skip"])
+	}
+
+	if _, ok := qp["$top"]; ok {
+		t.Errorf(`expected no top, got "%s"`, qp["$top"])
+	}
+
+	if qp["$apply"] != opts.Apply {
+		t.Errorf(`wrong apply: expected "%s", got "%s"`, opts.Apply, qp["$apply"])
+	}
+
+	// Top and Skip together
+	opts = ListOptions{Top: 5, Skip: 10}
+	qp = opts.BuildQueryParams("", nil)
+
+	if qp["$top"] != "5" || qp["$skip"] != "10" {
+		t.Errorf(`wrong top and skip: expected "5" and "10", got "%s" and "%s"`, qp["$top"], qp["$skip"])
+	}
+
+}
@@ -13,20 +13,37 @@ import (
 )
 
 const ContentTypeJSON = "application/json"
+const ContentTypeOctetStream = "application/octet-stream"
 const NoODATAMetadata = "odata.metadata=none"
+const MinimalODATAMetadata = "odata.metadata=minimal"
 const DataAccessReadOnly = "ReadOnly"
 
 // This is the "Accept" header value to return JSON without the OData metadata.
-// It's semicolon separated. Included in all requests.
+// It's semicolon separated.
 var AcceptJSONNoMetadata = strings.Join([]string{ContentTypeJSON, NoODATAMetadata}, ";")
 
+// This is the "Accept" header value to return JSON with the minimal OData metadata,
+// which includes the "@odata.etag" of each record. Included in all requests.
+var AcceptJSONMinimalMetadata = strings.Join([]string{ContentTypeJSON, MinimalODATAMetadata}, ";")
+
 // MakeRequestOptions are the unique options for the http.Request.
 type RequestOptions struct {
 	Method        string
 	EntitySetName string
 	RecordID      uuid.UUID
-	QueryParams   QueryParams
-	Body          any
+	// Path is added after the entity set and RecordID, e.g.
+	// "Microsoft.NAV.post" for a bound action.
+	Path        string
+	QueryParams QueryParams
+	// Body is marshaled to JSON, unless it is an io.Reader, which
+	// is streamed as is with the Content-Type "application/octet-stream".
+	Body any
+	// ETag is sent in the If-Match header of PATCH, PUT and DELETE
+	// requests. Defaults to "*", which matches any version of the record.
+	ETag ETag
+	// Header is added to the request after the default headers,
+	// replacing any default with the same key.
+	Header http.Header
 }
 
 // Validate checks all the fields for invalid combinations or values.
@@ -54,6 +71,12 @@ func (r RequestOptions) Validate() error {
 			errs = append(errs, fmt.Sprintf("invalid combination: cannot have $filter query param with method %s", r.Method))
 		}
 	}
+	// ETag is only used with If-Match
+	if r.ETag != "" {
+		if r.Method != http.MethodPatch && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			errs = append(errs, fmt.Sprintf("invalid combination: cannot have ETag with method %s", r.Method))
+		}
+	}
 	if r.Method == http.MethodPatch && r.RecordID == uuid.Nil {
 		errs = append(errs, "invalid combination: cannot have method PATCH with no RecordID")
 	}
@@ -77,51 +100,86 @@ func (c *Client) NewRequest(ctx context.Context, opts RequestOptions) (*http.Req
 	}
 
 	// Build the full URL string
-	newURL := BuildRequestURL(*c.baseURL, opts.EntitySetName, opts.RecordID, opts.QueryParams)
+	newURL := opts.requestURL(*c.baseURL)
 
-	// Marshall JSON
+	// Marshall JSON, unless it is a stream such as media content
 	var body io.Reader
-	if opts.Body != nil {
-		b, err := json.Marshal(opts.Body)
+	switch b := opts.Body.(type) {
+	case nil:
+	case io.Reader:
+		body = b
+	default:
+		raw, err := json.Marshal(opts.Body)
 		if err != nil {
 			return nil, fmt.Errorf("cannot marshal body %s: %w", opts.Body, err)
 		}
-		body = bytes.NewReader(b)
+		body = bytes.NewReader(raw)
 	}
 
-	// Create Request
-	req, err := http.NewRequestWithContext(ctx, opts.Method, newURL.String(), body)
+	// Create Request with the Authorization and Accept headers
+	req, err := c.newRequestURL(ctx, opts.Method, newURL.String(), body)
 	if err != nil {
-		return nil, fmt.Errorf("creating new request: %w", err)
+		return nil, err
 	}
 
-	// Add the Authorization header for each request
-	bearerToken, err := getBearerToken(ctx, c.authClient)
-	if err != nil {
-		return nil, fmt.Errorf("create auth header: %w", err)
-	}
-	req.Header.Set("Authorization", bearerToken)
+	setRequestHeaders(req.Header, opts)
+
+	return req, nil
 
-	// Add this header so it doesn't return the extra OData fields
-	req.Header.Set("Accept", AcceptJSONNoMetadata)
+}
 
+// setRequestHeaders sets the headers that depend on the method
+// and then adds the extra headers from the options.
+func setRequestHeaders(h http.Header, opts RequestOptions) {
 	// Use ReadOnly for GET
 	if opts.Method == http.MethodGet {
-		req.Header.Set("Data-Access-Intent", DataAccessReadOnly)
+		h.Set("Data-Access-Intent", DataAccessReadOnly)
 	}
 
-	// Use JSON for POST, PUT, PATCH
+	// Use JSON for POST, PUT, PATCH, or binary for a stream
 	if opts.Method == http.MethodPost || opts.Method == http.MethodPut || opts.Method == http.MethodPatch {
-		req.Header.Set("Content-Type", ContentTypeJSON)
+		if _, ok := opts.Body.(io.Reader); ok {
+			h.Set("Content-Type", ContentTypeOctetStream)
+		} else {
+			h.Set("Content-Type", ContentTypeJSON)
+		}
 	}
 
-	// Use If-Match for POST, PUT, PATCH, DELETE
+	// Use If-Match for PUT, PATCH, DELETE, matching any version unless there is an ETag
 	if opts.Method == http.MethodDelete || opts.Method == http.MethodPut || opts.Method == http.MethodPatch {
-		req.Header.Set("If-Match", "*")
+		ifMatch := "*"
+		if opts.ETag != "" {
+			ifMatch = string(opts.ETag)
+		}
+		h.Set("If-Match", ifMatch)
 	}
 
-	return req, nil
+	// Add or replace with the extra headers
+	for k, v := range opts.Header {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+}
+
+// newRequestURL creates the http.Request for an absolute URL and adds the
+// Authorization and Accept headers that are included in all requests.
+func (c *Client) newRequestURL(ctx context.Context, method string, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating new request: %w", err)
+	}
+
+	// Add the Authorization header for each request
+	bearerToken, err := getBearerToken(ctx, c.authClient)
+	if err != nil {
+		return nil, fmt.Errorf("create auth header: %w", err)
+	}
+	req.Header.Set("Authorization", bearerToken)
+
+	// Add this header so it returns the "@odata.etag" of the records,
+	// which is left out with "odata.metadata=none"
+	req.Header.Set("Accept", AcceptJSONMinimalMetadata)
 
+	return req, nil
 }
 
 // getBearerToken gets the AccessToken and creates a Bearer token.
@@ -135,7 +193,12 @@ func getBearerToken(ctx context.Context, tg TokenGetter) (string, error) {
 
 }
 
-// Do calls Do on the baseClient.
+// Do calls Do on the baseClient. If the Client has a [RetryPolicy]
+// the request is retried when it is throttled or fails with a transient error.
+// Each attempt waits for the rate and concurrency limiters, if set.
 func (c *Client) Do(r *http.Request) (*http.Response, error) {
-	return c.baseClient.Do(r)
+	if c.retryPolicy == nil || !c.retryPolicy.shouldRetry(r) {
+		return c.send(r)
+	}
+	return c.doWithRetry(r, *c.retryPolicy)
 }
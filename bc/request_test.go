@@ -55,7 +55,7 @@ func TestMakeRequestGetNoParams(t *testing.T) {
 		{"Method", req.Method, "GET"},
 		{"Query", req.URL.RawQuery, ""},
 		// Get values and join together with separator so multiple values under same key fail
-		{"Header_Accept", strings.Join(req.Header.Values("Accept"), "--"), bc.AcceptJSONNoMetadata},
+		{"Header_Accept", strings.Join(req.Header.Values("Accept"), "--"), bc.AcceptJSONMinimalMetadata},
 		{"Header_ContentType", strings.Join(req.Header.Values("Content-Type"), "--"), ""},
 		{"Header_DataAccessIntent", strings.Join(req.Header.Values("Data-Access-Intent"), "--"), bc.DataAccessReadOnly},
 		{"Header_IfMatch", strings.Join(req.Header.Values("If-Match"), "--"), ""},
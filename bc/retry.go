@@ -0,0 +1,140 @@
+package bc
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how [Client.Do] retries requests that were throttled (429)
+// or failed with a transient server error (503, 504).
+// Requests are only retried if they are idempotent: GET, HEAD, OPTIONS, PUT, DELETE
+// and PATCH with an ETag in the If-Match header. POST and PATCH with If-Match "*"
+// are only retried if RetryNonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts        int           // The total number of attempts, including the first.
+	BaseDelay          time.Duration // The delay before the first retry. Doubled for each retry after.
+	MaxDelay           time.Duration // The maximum delay between attempts when there is no Retry-After header.
+	Jitter             float64       // The fraction (0-1) of the delay that is randomized.
+	RetryNonIdempotent bool          // Also retry POST and PATCH without an ETag.
+}
+
+// DefaultRetryPolicy is a reasonable policy for the BC service limits.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.2,
+}
+
+// shouldRetry returns true if the request can be sent again.
+func (p RetryPolicy) shouldRetry(r *http.Request) bool {
+	// The body must be replayable
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPatch:
+		ifMatch := r.Header.Get("If-Match")
+		if ifMatch != "" && ifMatch != "*" {
+			return true
+		}
+	}
+
+	return p.RetryNonIdempotent
+}
+
+// delay returns the time to wait before the next attempt. It uses
+// the Retry-After header if the server sent one.
+func (p RetryPolicy) delay(attempt int, retryAfter string) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter); ok {
+		return d
+	}
+
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 {
+		// Overflowed
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (rand.Float64()*2 - 1))
+	}
+
+	// Cap after the jitter so the wait never exceeds MaxDelay
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
+
+// parseRetryAfter parses the Retry-After header as either seconds
+// or an HTTP date.
+func parseRetryAfter(s string) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if t, err := http.ParseTime(s); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// isRetryableStatus returns true for throttled and transient status codes.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// doWithRetry sends the request and retries it according to the policy.
+func (c *Client) doWithRetry(r *http.Request, policy RetryPolicy) (*http.Response, error) {
+	req := r
+
+	for attempt := 1; ; attempt++ {
+		res, err := c.send(req)
+		if err != nil || attempt >= policy.MaxAttempts || !isRetryableStatus(res.StatusCode) {
+			return res, err
+		}
+
+		delay := policy.delay(attempt, res.Header.Get("Retry-After"))
+
+		// Drain the body so the connection can be reused
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		c.logger.Debug("Retrying request.", "method", r.Method, "url", r.URL.String(), "status", res.StatusCode, "attempt", attempt, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		case <-timer.C:
+		}
+
+		// Replay the body for the next attempt
+		req = r.Clone(r.Context())
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
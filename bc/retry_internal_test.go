@@ -0,0 +1,18 @@
+package bc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayMaxDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second, Jitter: 1}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		for range 100 {
+			if d := p.delay(attempt, ""); d > p.MaxDelay {
+				t.Fatalf("attempt %d: wanted at most %s, got %s", attempt, p.MaxDelay, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package bc_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/internal/bctest"
+	"github.com/google/uuid"
+)
+
+var testRetryPolicy = bc.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    5 * time.Millisecond,
+}
+
+// newRetryClient returns a client whose transport responds with the statuses in order
+// and records the request bodies.
+func newRetryClient(t *testing.T, policy bc.RetryPolicy, statuses []int, bodies *[]string) *bc.Client {
+	t.Helper()
+
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		if r.Body != nil {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = string(b)
+		}
+		*bodies = append(*bodies, body)
+
+		status := statuses[len(*bodies)-1]
+		header := http.Header{}
+		if status == http.StatusTooManyRequests {
+			header.Set("Retry-After", "0")
+		}
+
+		return &http.Response{StatusCode: status, Header: header, Body: bctest.NewRequestBody(map[string]any{"ID": "1"}), Request: r}, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}), bc.WithRetry(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestRetryGet(t *testing.T) {
+	var bodies []string
+	client := newRetryClient(t, testRetryPolicy, []int{429, 503, 200}, &bodies)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+	if _, err := page.Get(context.Background(), uuid.New(), bc.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 3 {
+		t.Errorf("wanted 3 attempts, got %d", len(bodies))
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	var bodies []string
+	client := newRetryClient(t, testRetryPolicy, []int{504, 504, 504, 200}, &bodies)
+
+	page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+	if _, err := page.Get(context.Background(), uuid.New(), bc.GetOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if len(bodies) != 3 {
+		t.Errorf("wanted 3 attempts, got %d", len(bodies))
+	}
+}
+
+func TestRetryPost(t *testing.T) {
+	body := map[string]any{"number": "1000"}
+
+	t.Run("NotRetried", func(t *testing.T) {
+		var bodies []string
+		client := newRetryClient(t, testRetryPolicy, []int{429, 201}, &bodies)
+
+		page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+		if _, err := page.Create(context.Background(), body, bc.GetOptions{}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if len(bodies) != 1 {
+			t.Errorf("wanted 1 attempt, got %d", len(bodies))
+		}
+	})
+
+	t.Run("RetryNonIdempotent", func(t *testing.T) {
+		policy := testRetryPolicy
+		policy.RetryNonIdempotent = true
+
+		var bodies []string
+		client := newRetryClient(t, policy, []int{429, 201}, &bodies)
+
+		page := bc.NewAPIPage[fakeEntity](client, "fakeEntities")
+		if _, err := page.Create(context.Background(), body, bc.GetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bodies) != 2 {
+			t.Fatalf("wanted 2 attempts, got %d", len(bodies))
+		}
+
+		want := `{"number":"1000"}`
+		for i, got := range bodies {
+			if got != want {
+				t.Errorf("attempt %d: wanted body %s, got %s", i, want, got)
+			}
+		}
+	})
+}
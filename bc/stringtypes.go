@@ -3,6 +3,9 @@ package bc
 import (
 	"fmt"
 	"unicode/utf8"
+
+	"github.com/erlorenz/bc-go/bc/filter"
+	"github.com/google/uuid"
 )
 
 // GUID represents a Microsoft GUID and implements the Validator interface.
@@ -18,3 +21,15 @@ func (id GUID) Validate() error {
 	return nil
 
 }
+
+// ODataLiteral formats the GUID without quotes, as used in a $filter.
+// An invalid GUID is quoted like a string, which the server rejects.
+func (id GUID) ODataLiteral() string {
+	u, err := uuid.Parse(string(id))
+	if err != nil {
+		// A string is always supported
+		s, _ := filter.Format(string(id))
+		return s
+	}
+	return u.String()
+}
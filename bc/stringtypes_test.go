@@ -0,0 +1,27 @@
+package bc_test
+
+import (
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/filter"
+)
+
+func TestGUIDFilter(t *testing.T) {
+	table := []struct {
+		name string
+		id   bc.GUID
+		want string
+	}{
+		{"Valid", bc.GUID("7D5D2CB1-4F2B-4BD4-9BB5-2C0A3C6C2F6A"), "id eq 7d5d2cb1-4f2b-4bd4-9bb5-2c0a3c6c2f6a"},
+		{"Invalid", bc.GUID("not a guid"), "id eq 'not a guid'"},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			if got := filter.Eq("id", test.id).String(); got != test.want {
+				t.Errorf("wanted %s, got %s", test.want, got)
+			}
+		})
+	}
+}
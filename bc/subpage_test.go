@@ -0,0 +1,141 @@
+package bc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/google/uuid"
+)
+
+type fakeOrder struct {
+	bc.ETagged
+	ID     uuid.UUID `json:"id"`
+	Number string    `json:"number"`
+}
+
+func (f fakeOrder) Validate() error {
+	return nil
+}
+
+type fakeOrderLine struct {
+	bc.ETagged
+	ID          uuid.UUID `json:"id"`
+	DocumentID  uuid.UUID `json:"documentId"`
+	Description string    `json:"description"`
+}
+
+func (f fakeOrderLine) Validate() error {
+	return nil
+}
+
+type fakeLineComment struct {
+	ID     uuid.UUID `json:"id"`
+	LineID uuid.UUID `json:"lineId"`
+	Text   string    `json:"text"`
+}
+
+func (f fakeLineComment) Validate() error {
+	return nil
+}
+
+func TestSubPage(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	srv.AddNavigation("salesOrders", "salesOrderLines", bcfake.Navigation{EntitySet: "salesOrderLines", ForeignKey: "documentId", Collection: true})
+	srv.AddNavigation("salesOrderLines", "comments", bcfake.Navigation{EntitySet: "lineComments", ForeignKey: "lineId", Collection: true})
+
+	orderID, err := srv.Insert("salesOrders", fakeOrder{Number: "S-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := srv.Insert("salesOrders", fakeOrder{Number: "S-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Insert("salesOrderLines", fakeOrderLine{DocumentID: otherID, Description: "Other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	orders := bc.NewAPIPage[fakeOrder](client, "salesOrders")
+	lines := bc.NewSubPage[fakeOrderLine](orders, orderID, "salesOrderLines")
+
+	line, err := lines.Create(ctx, map[string]any{"description": "Bicycle"}, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.DocumentID != orderID {
+		t.Errorf("wanted documentId %s, got %s", orderID, line.DocumentID)
+	}
+
+	// Only the lines of the order
+	list, err := lines.List(ctx, bc.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Description != "Bicycle" {
+		t.Errorf("wanted only the Bicycle line, got %+v", list)
+	}
+
+	if _, err := lines.UpdateIfMatch(ctx, line.ID, line.ETag, nil, map[string]any{"description": "Bike"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deep nesting
+	comments := bc.NewSubPage[fakeLineComment](lines, line.ID, "comments")
+	comment, err := comments.Create(ctx, map[string]any{"text": "Red"}, bc.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.LineID != line.ID {
+		t.Errorf("wanted lineId %s, got %s", line.ID, comment.LineID)
+	}
+
+	// A line of another order is not found
+	otherLines := bc.NewSubPage[fakeOrderLine](orders, otherID, "salesOrderLines")
+	if _, err := otherLines.Get(ctx, line.ID, bc.GetOptions{}); !bc.IsNotFound(err) {
+		t.Errorf("wanted not found, got %v", err)
+	}
+
+	if err := lines.Delete(ctx, line.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubPagePanic(t *testing.T) {
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := bc.NewAPIPage[fakeOrder](client, "salesOrders")
+
+	tests := []struct {
+		name       string
+		parent     *bc.APIPage[fakeOrder]
+		parentID   uuid.UUID
+		navigation string
+	}{
+		{"nil parent", nil, uuid.New(), "salesOrderLines"},
+		{"empty parentID", orders, uuid.Nil, "salesOrderLines"},
+		{"empty navigation", orders, uuid.New(), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic, got nil")
+				}
+			}()
+			bc.NewSubPage[fakeOrderLine](tt.parent, tt.parentID, tt.navigation)
+		})
+	}
+}
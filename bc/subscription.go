@@ -0,0 +1,347 @@
+package bc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionLifetime is how long a webhook subscription lasts after it
+// was created or renewed. It must be renewed before it expires.
+const SubscriptionLifetime = 3 * 24 * time.Hour
+
+// MaxClientStateLength is the maximum length of the clientState of a subscription.
+const MaxClientStateLength = 2048
+
+// Subscription is a webhook subscription, as returned by the subscriptions API.
+// BC posts a notification to the NotificationURL when a record of the Resource changes.
+type Subscription struct {
+	ETagged
+	SubscriptionID       string    `json:"subscriptionId"`
+	NotificationURL      string    `json:"notificationUrl"`
+	Resource             string    `json:"resource"`
+	UserID               uuid.UUID `json:"userId"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+	ClientState          string    `json:"clientState"`
+	ExpirationDateTime   time.Time `json:"expirationDateTime"`
+}
+
+// Validate implements the Validator interface.
+func (s Subscription) Validate() error {
+	if s.SubscriptionID == "" {
+		return errors.New("subscription id is empty")
+	}
+	return nil
+}
+
+// SubscriptionRequest is the body to create or renew a [Subscription].
+type SubscriptionRequest struct {
+	// NotificationURL receives the notifications, e.g. with a webhook.Handler.
+	// BC validates it when the subscription is created or renewed.
+	NotificationURL string `json:"notificationUrl"`
+	// Resource is the path of the entity set, e.g. from [Client.SubscriptionResource].
+	Resource string `json:"resource"`
+	// ClientState is sent back with each notification so the receiver can
+	// check that it came from BC. Optional, but a webhook.Handler requires
+	// it unless the check is turned off.
+	ClientState string `json:"clientState,omitempty"`
+}
+
+// Validate implements the Validator interface.
+func (r SubscriptionRequest) Validate() error {
+	var errs []string
+
+	if r.NotificationURL == "" {
+		errs = append(errs, fmt.Sprintf("NotificationURL: %s", ErrorEmptyString))
+	}
+	if r.Resource == "" {
+		errs = append(errs, fmt.Sprintf("Resource: %s", ErrorEmptyString))
+	}
+	if len(r.ClientState) > MaxClientStateLength {
+		errs = append(errs, fmt.Sprintf("ClientState: longer than %d characters", MaxClientStateLength))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("validation: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// SubscriptionResource returns the resource of an entity set of the company of
+// the Client to subscribe to, e.g. "/api/v2.0/companies({companyID})/customers".
+func (c *Client) SubscriptionResource(entitySetName string) string {
+	return fmt.Sprintf("/api/%s/companies(%s)/%s", c.config.APIEndpoint, c.config.CompanyID, entitySetName)
+}
+
+// Subscriptions manages the webhook subscriptions of the environment.
+// They are always on the "v2.0" endpoint, whatever the endpoint of the Client.
+type Subscriptions struct {
+	client *Client
+}
+
+// Subscriptions returns the [Subscriptions] for the environment of the Client.
+func (c *Client) Subscriptions() *Subscriptions {
+	return &Subscriptions{client: c}
+}
+
+// Create makes a POST request to create a subscription. BC validates the
+// NotificationURL before it responds.
+func (s *Subscriptions) Create(ctx context.Context, r SubscriptionRequest) (Subscription, error) {
+	if err := r.Validate(); err != nil {
+		return Subscription{}, fmt.Errorf("create subscription: %w", err)
+	}
+	return s.send(ctx, http.MethodPost, "", "", r)
+}
+
+// List makes a GET request for all the subscriptions of the environment.
+func (s *Subscriptions) List(ctx context.Context) ([]Subscription, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, "", "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Request: %w", err)
+	}
+	return listAll[Subscription](s.client, req, 0)
+}
+
+// Get makes a GET request for the subscription with the id.
+func (s *Subscriptions) Get(ctx context.Context, id string) (Subscription, error) {
+	if id == "" {
+		return Subscription{}, errors.New("get subscription: id is empty")
+	}
+	return s.send(ctx, http.MethodGet, id, "", nil)
+}
+
+// Renew makes a PATCH request with the ETag of the subscription, which extends
+// it for another [SubscriptionLifetime]. If the subscription was changed since
+// it was read, the error matches [ErrPreconditionFailed].
+func (s *Subscriptions) Renew(ctx context.Context, sub Subscription) (Subscription, error) {
+	if sub.SubscriptionID == "" || sub.ETag == "" {
+		return Subscription{}, errors.New("renew subscription: id and etag are required")
+	}
+
+	r := SubscriptionRequest{
+		NotificationURL: sub.NotificationURL,
+		Resource:        sub.Resource,
+		ClientState:     sub.ClientState,
+	}
+	return s.send(ctx, http.MethodPatch, sub.SubscriptionID, sub.ETag, r)
+}
+
+// Delete makes a DELETE request for the subscription. It deletes it even if
+// it was changed since it was read, unless the etag is set.
+func (s *Subscriptions) Delete(ctx context.Context, id string, etag ETag) error {
+	if id == "" {
+		return errors.New("delete subscription: id is empty")
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, id, etag, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed during request: %w", err)
+	}
+
+	if err := DecodeNoContent(res); err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			s.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return fmt.Errorf("error from BC API: %w", srvErr)
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send makes a request that returns a Subscription.
+func (s *Subscriptions) send(ctx context.Context, method string, id string, etag ETag, body any) (Subscription, error) {
+	req, err := s.newRequest(ctx, method, id, etag, body)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to create Request: %w", err)
+	}
+
+	s.client.logger.Debug("Sending request...", "url", req.URL.String(), "method", req.Method)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed during request: %w", err)
+	}
+
+	v, err := Decode[Subscription](res)
+	if err != nil {
+		var srvErr APIError
+		if errors.As(err, &srvErr) {
+			s.client.logger.Debug("API server returned error response.", "error", srvErr)
+			return v, fmt.Errorf("error from BC API: %w", srvErr)
+		}
+
+		s.client.logger.Debug("Failed to decode response.", "error", err)
+		return v, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// The ETag header has the same value, in case the body does not
+	if v.ETag == "" {
+		v.ETag = ETag(res.Header.Get("ETag"))
+	}
+	return v, nil
+}
+
+// newRequest creates the request for the subscriptions, or the one with the id.
+func (s *Subscriptions) newRequest(ctx context.Context, method string, id string, etag ETag, body any) (*http.Request, error) {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal body %s: %w", body, err)
+		}
+		r = bytes.NewReader(raw)
+	}
+
+	req, err := s.client.newRequestURL(ctx, method, s.url(id), r)
+	if err != nil {
+		return nil, err
+	}
+	setRequestHeaders(req.Header, RequestOptions{Method: method, Body: body, ETag: etag})
+
+	return req, nil
+}
+
+// url returns "{apiRoot}/v2.0/subscriptions", or "subscriptions('{id}')".
+// It uses the v2.0 endpoint even if the Client has a custom endpoint.
+func (s *Subscriptions) url(id string) string {
+	rootURL := s.client.apiRootURL()
+	rootURL.Path = strings.TrimSuffix(rootURL.Path, "/"+s.client.config.APIEndpoint) + "/v2.0/subscriptions"
+	if id != "" {
+		rootURL.Path += "('" + strings.ReplaceAll(id, "'", "''") + "')"
+	}
+	return rootURL.String()
+}
+
+// DefaultRenewBefore is how long before a subscription expires the [SubscriptionRenewer] renews it.
+const DefaultRenewBefore = 24 * time.Hour
+
+// DefaultRenewInterval is how often [SubscriptionRenewer.Run] checks the subscriptions.
+const DefaultRenewInterval = time.Hour
+
+// SubscriptionRenewer keeps a set of subscriptions alive by renewing them before
+// they expire. Create one with [Subscriptions.NewRenewer], add the ids and call Run.
+// It is safe for concurrent use.
+type SubscriptionRenewer struct {
+	subscriptions *Subscriptions
+	renewBefore   time.Duration
+
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// NewRenewer creates a [SubscriptionRenewer] that renews the subscriptions that expire
+// within renewBefore. Zero uses [DefaultRenewBefore].
+func (s *Subscriptions) NewRenewer(renewBefore time.Duration) *SubscriptionRenewer {
+	if renewBefore <= 0 {
+		renewBefore = DefaultRenewBefore
+	}
+	return &SubscriptionRenewer{
+		subscriptions: s,
+		renewBefore:   renewBefore,
+		ids:           map[string]bool{},
+	}
+}
+
+// Add adds the subscription ids to keep alive.
+func (r *SubscriptionRenewer) Add(ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.ids[id] = true
+	}
+}
+
+// Remove stops renewing the subscription. It does not delete it.
+func (r *SubscriptionRenewer) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.ids, id)
+}
+
+// IDs returns the ids of the subscriptions that are kept alive.
+func (r *SubscriptionRenewer) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.ids))
+	for id := range r.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// RenewDue gets each subscription and renews the ones that expire within renewBefore.
+// It returns the renewed subscriptions. Subscriptions that no longer exist are
+// removed, and their error matches [ErrNotFound].
+func (r *SubscriptionRenewer) RenewDue(ctx context.Context) ([]Subscription, error) {
+	var renewed []Subscription
+	var errs []error
+
+	for _, id := range r.IDs() {
+		sub, err := r.subscriptions.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				r.Remove(id)
+			}
+			errs = append(errs, fmt.Errorf("subscription %s: %w", id, err))
+			continue
+		}
+
+		if time.Until(sub.ExpirationDateTime) > r.renewBefore {
+			continue
+		}
+
+		sub, err = r.subscriptions.Renew(ctx, sub)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", id, err))
+			continue
+		}
+
+		r.subscriptions.client.logger.Debug("Renewed subscription.", "id", id, "expiration", sub.ExpirationDateTime)
+		renewed = append(renewed, sub)
+	}
+
+	return renewed, errors.Join(errs...)
+}
+
+// Run calls RenewDue every interval until the context is done. Zero uses
+// [DefaultRenewInterval]. Errors are logged and retried at the next interval.
+// It returns the error of the context.
+func (r *SubscriptionRenewer) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultRenewInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RenewDue(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.subscriptions.client.logger.Error("Failed to renew subscriptions.", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package bc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/internal/bctest"
+)
+
+// newEchoReceiver responds to the validation request with the validationToken.
+func newEchoReceiver(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("validationToken")))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSubscriptions(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+	receiver := newEchoReceiver(t)
+
+	// Subscriptions use the v2.0 endpoint even for an extension API client
+	config := srv.ClientConfig()
+	config.APIEndpoint = "contoso/app/v1.0"
+	client, err := bc.NewClient(config, bc.WithAuthClient(bcfake.TokenGetter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := client.Subscriptions()
+	ctx := context.Background()
+
+	resource := client.SubscriptionResource("customers")
+	if want := "/api/contoso/app/v1.0/companies(" + srv.CompanyID + ")/customers"; resource != want {
+		t.Errorf("wanted resource %s, got %s", want, resource)
+	}
+
+	sub, err := subs.Create(ctx, bc.SubscriptionRequest{
+		NotificationURL: receiver.URL,
+		Resource:        resource,
+		ClientState:     "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.SubscriptionID == "" || sub.ETag == "" || sub.ClientState != "secret" {
+		t.Errorf("wanted subscription with id, etag and client state, got %+v", sub)
+	}
+	if time.Until(sub.ExpirationDateTime) < bc.SubscriptionLifetime-time.Minute {
+		t.Errorf("wanted expiration in 3 days, got %s", sub.ExpirationDateTime)
+	}
+
+	list, err := subs.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].SubscriptionID != sub.SubscriptionID {
+		t.Errorf("wanted the subscription listed, got %+v", list)
+	}
+
+	renewed, err := subs.Renew(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.ETag == sub.ETag {
+		t.Error("wanted a new etag after renewal")
+	}
+
+	// The old etag no longer matches
+	if _, err := subs.Renew(ctx, sub); !errors.Is(err, bc.ErrPreconditionFailed) {
+		t.Errorf("wanted ErrPreconditionFailed, got %v", err)
+	}
+
+	if err := subs.Delete(ctx, sub.SubscriptionID, renewed.ETag); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := subs.Get(ctx, sub.SubscriptionID); !errors.Is(err, bc.ErrNotFound) {
+		t.Errorf("wanted ErrNotFound, got %v", err)
+	}
+}
+
+func TestSubscriptionsCreateValidation(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := client.Subscriptions()
+
+	if _, err := subs.Create(context.Background(), bc.SubscriptionRequest{Resource: "customers"}); err == nil {
+		t.Error("wanted validation error without notification URL")
+	}
+
+	// The receiver does not echo the validation token
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	_, err = subs.Create(context.Background(), bc.SubscriptionRequest{
+		NotificationURL: receiver.URL,
+		Resource:        client.SubscriptionResource("customers"),
+	})
+	var apiErr bc.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("wanted 400 APIError, got %v", err)
+	}
+}
+
+func TestSubscriptionRenewer(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+	receiver := newEchoReceiver(t)
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := client.Subscriptions()
+	ctx := context.Background()
+
+	sub, err := subs.Create(ctx, bc.SubscriptionRequest{
+		NotificationURL: receiver.URL,
+		Resource:        client.SubscriptionResource("customers"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is due within an hour
+	renewer := subs.NewRenewer(time.Hour)
+	renewer.Add(sub.SubscriptionID)
+	renewed, err := renewer.RenewDue(ctx)
+	if err != nil || len(renewed) != 0 {
+		t.Fatalf("wanted nothing renewed, got %v %v", renewed, err)
+	}
+
+	// Everything is due within 4 days, and the missing subscription is removed
+	renewer = subs.NewRenewer(4 * 24 * time.Hour)
+	renewer.Add(sub.SubscriptionID, "missing")
+	renewed, err = renewer.RenewDue(ctx)
+	if !errors.Is(err, bc.ErrNotFound) {
+		t.Errorf("wanted ErrNotFound for the missing subscription, got %v", err)
+	}
+	if len(renewed) != 1 || renewed[0].ETag == sub.ETag {
+		t.Errorf("wanted the subscription renewed, got %+v", renewed)
+	}
+	if ids := renewer.IDs(); len(ids) != 1 || ids[0] != sub.SubscriptionID {
+		t.Errorf("wanted only %s kept, got %v", sub.SubscriptionID, ids)
+	}
+}
+
+func TestSubscriptionsETagHeader(t *testing.T) {
+	transport := bctest.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		// The body has no "@odata.etag", only the header
+		res := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Etag": {`W/"1"`}},
+			Body:       io.NopCloser(strings.NewReader(`{"subscriptionId":"abc","notificationUrl":"https://example.com","resource":"customers"}`)),
+		}
+		if r.Method == http.MethodPatch {
+			if got := r.Header.Get("If-Match"); got != `W/"1"` {
+				t.Errorf("wanted If-Match from the ETag header, got %q", got)
+			}
+			res.Header.Set("ETag", `W/"2"`)
+		}
+		return res, nil
+	})
+
+	client, err := bc.NewClient(fakeConfig, bc.WithAuthClient(fakeTokenGetter{}), bc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := client.Subscriptions()
+	ctx := context.Background()
+
+	sub, err := subs.Get(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewed, err := subs.Renew(ctx, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.ETag != `W/"2"` {
+		t.Errorf("wanted the new ETag, got %q", renewed.ETag)
+	}
+
+	// A zero interval uses the default instead of panicking
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := subs.NewRenewer(0).Run(canceled, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("wanted context canceled, got %v", err)
+	}
+}
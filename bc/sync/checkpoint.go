@@ -0,0 +1,168 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Checkpoint is the progress of a [Syncer]. It is saved after every pull.
+type Checkpoint struct {
+	// LastModified is the latest modified time of the handled records.
+	LastModified time.Time `json:"lastModified,omitzero"`
+	// Seen has the modified time of the handled records within the overlap
+	// window, so they are not handled again when they are read again.
+	Seen map[uuid.UUID]time.Time `json:"seen,omitempty"`
+	// LastReconciled is when the last full reconciliation started.
+	LastReconciled time.Time `json:"lastReconciled,omitzero"`
+}
+
+// add records that the record was handled.
+func (cp *Checkpoint) add(id uuid.UUID, modified time.Time) {
+	if cp.Seen == nil {
+		cp.Seen = map[uuid.UUID]time.Time{}
+	}
+	cp.Seen[id] = modified
+
+	if modified.After(cp.LastModified) {
+		cp.LastModified = modified
+	}
+}
+
+// seen returns true if the record was handled with the same modified time.
+func (cp *Checkpoint) seen(id uuid.UUID, modified time.Time) bool {
+	t, ok := cp.Seen[id]
+	return ok && t.Equal(modified)
+}
+
+// prune removes the records that are older than the overlap window.
+func (cp *Checkpoint) prune(overlap time.Duration) {
+	from := cp.LastModified.Add(-overlap)
+	for id, modified := range cp.Seen {
+		if modified.Before(from) {
+			delete(cp.Seen, id)
+		}
+	}
+}
+
+// CheckpointStore persists the Checkpoint of each Syncer by name.
+type CheckpointStore interface {
+	// Load returns the Checkpoint, or a zero Checkpoint if none was saved.
+	Load(ctx context.Context, name string) (Checkpoint, error)
+	// Save replaces the Checkpoint.
+	Save(ctx context.Context, name string, cp Checkpoint) error
+}
+
+// MemoryStore is a CheckpointStore that keeps the checkpoints in memory.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu          gosync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryStore creates an empty [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{checkpoints: map[string]Checkpoint{}}
+}
+
+// Load implements the CheckpointStore interface.
+func (m *MemoryStore) Load(_ context.Context, name string) (Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoints[name].clone(), nil
+}
+
+// Save implements the CheckpointStore interface.
+func (m *MemoryStore) Save(_ context.Context, name string, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[name] = cp.clone()
+	return nil
+}
+
+// clone copies the Seen map so the stored Checkpoint is not shared.
+func (cp Checkpoint) clone() Checkpoint {
+	if cp.Seen != nil {
+		seen := make(map[uuid.UUID]time.Time, len(cp.Seen))
+		for id, t := range cp.Seen {
+			seen[id] = t
+		}
+		cp.Seen = seen
+	}
+	return cp
+}
+
+// FileStore is a CheckpointStore that saves each checkpoint as a JSON file
+// in a directory. Files are replaced atomically.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a [FileStore]. The directory is created when needed.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Load implements the CheckpointStore interface.
+func (f *FileStore) Load(_ context.Context, name string) (Checkpoint, error) {
+	var cp Checkpoint
+
+	b, err := os.ReadFile(f.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return cp, fmt.Errorf("decode checkpoint %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// Save implements the CheckpointStore interface.
+func (f *FileStore) Save(_ context.Context, name string, cp Checkpoint) error {
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode checkpoint %s: %w", name, err)
+	}
+
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return fmt.Errorf("create checkpoint directory: %w", err)
+	}
+
+	// Write a temp file and rename it so a crash cannot leave a partial checkpoint
+	tmp, err := os.CreateTemp(f.dir, ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path(name)); err != nil {
+		return fmt.Errorf("replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// path escapes the name, which can have a "/" for a sub page.
+func (f *FileStore) path(name string) string {
+	return filepath.Join(f.dir, url.PathEscape(name)+".json")
+}
@@ -0,0 +1,339 @@
+// Package sync pulls the records of an [bc.APIPage] that changed since the
+// last pull, using the lastModifiedDateTime of the records, and passes them
+// to a [Handler]. The progress is saved as a [Checkpoint] in a [CheckpointStore].
+//
+//	syncer := sync.New(customers, sync.NewFileStore("checkpoints"), handler,
+//		func(c Customer) uuid.UUID { return c.ID },
+//		func(c Customer) time.Time { return c.LastModifiedDateTime },
+//	)
+//	err := syncer.Run(ctx, time.Minute, 24*time.Hour)
+//
+// Records are read in the order of their modified time and id. Each pull reads
+// again from the last modified time minus an overlap window, because a record
+// can be committed with a modified time that is slightly older than a record
+// that was already read, e.g. due to clock skew between servers. The records
+// that were already handled with the same modified time are skipped, so records
+// with equal timestamps are neither lost nor handled twice.
+//
+// Deletions cannot be detected from the modified time, so Reconcile compares
+// all the ids of the entity set with the ids known by the Handler.
+//
+// The package name clashes with the standard library sync package. Import one
+// of them with another name when both are needed, e.g. bcsync.
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/filter"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultModifiedField is the field used to find the changed records.
+	DefaultModifiedField = "lastModifiedDateTime"
+	// DefaultOverlap is how far before the last modified time each pull starts.
+	DefaultOverlap = 5 * time.Minute
+)
+
+// Handler receives the changes. Upsert and Delete must be idempotent, as
+// a record can be passed again if the checkpoint could not be saved.
+type Handler[T any] interface {
+	// Upsert creates or replaces the record.
+	Upsert(ctx context.Context, record T) error
+	// Delete removes the record with the id.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// IDs returns the ids of all the records that were upserted and
+	// not deleted. It is used by Reconcile.
+	IDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// Result counts the records handled by a pull or reconciliation.
+type Result struct {
+	Upserted int
+	Deleted  int
+	Skipped  int
+}
+
+// Option configures a [Syncer].
+type Option func(*options)
+
+type options struct {
+	name          string
+	modifiedField string
+	overlap       time.Duration
+	pageSize      int
+	logger        *slog.Logger
+}
+
+// WithName sets the name of the checkpoint instead of the entity set name.
+// Use it when several Syncers read the same entity set.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithModifiedField sets the field used to find the changed records instead
+// of [DefaultModifiedField]. It must match the modified func.
+func WithModifiedField(field string) Option {
+	return func(o *options) {
+		o.modifiedField = field
+	}
+}
+
+// WithOverlap sets the overlap window instead of [DefaultOverlap].
+func WithOverlap(overlap time.Duration) Option {
+	return func(o *options) {
+		o.overlap = overlap
+	}
+}
+
+// WithPageSize sets the preferred number of records per page.
+func WithPageSize(size int) Option {
+	return func(o *options) {
+		o.pageSize = size
+	}
+}
+
+// WithLogger sets a [slog.Logger] instead of the logger of the Client.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Syncer pulls the changed records of an APIPage. It is not safe
+// for concurrent use, Run calls Pull and Reconcile one at a time.
+type Syncer[T bc.Validator] struct {
+	page     *bc.APIPage[T]
+	store    CheckpointStore
+	handler  Handler[T]
+	id       func(T) uuid.UUID
+	modified func(T) time.Time
+	options
+}
+
+// New creates a [Syncer]. The id and modified funcs return the id and
+// lastModifiedDateTime of a record. It panics if any argument is nil.
+func New[T bc.Validator](page *bc.APIPage[T], store CheckpointStore, handler Handler[T], id func(T) uuid.UUID, modified func(T) time.Time, opts ...Option) *Syncer[T] {
+	if page == nil || store == nil || handler == nil || id == nil || modified == nil {
+		panic("create syncer: page, store, handler, id and modified are required")
+	}
+
+	o := options{
+		name:          page.EntitySetName(),
+		modifiedField: DefaultModifiedField,
+		overlap:       DefaultOverlap,
+		logger:        page.Client().Logger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Syncer[T]{
+		page:     page,
+		store:    store,
+		handler:  handler,
+		id:       id,
+		modified: modified,
+		options:  o,
+	}
+}
+
+// Name returns the name of the checkpoint.
+func (s *Syncer[T]) Name() string {
+	return s.name
+}
+
+// Pull reads the records that changed since the checkpoint and upserts them.
+// The first pull reads all the records. The checkpoint is saved even if it
+// fails, so the records that were handled are not handled again.
+func (s *Syncer[T]) Pull(ctx context.Context) (Result, error) {
+	var res Result
+
+	cp, err := s.store.Load(ctx, s.name)
+	if err != nil {
+		return res, fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	opts := bc.ListOptions{
+		OrderBy:     []string{s.modifiedField, "id"},
+		MaxPageSize: s.pageSize,
+	}
+	if !cp.LastModified.IsZero() {
+		opts.Filter = filter.Ge(s.modifiedField, cp.LastModified.Add(-s.overlap)).String()
+	}
+
+	s.logger.Debug("Pulling changes...", "name", s.name, "from", cp.LastModified)
+
+	pullErr := s.pull(ctx, opts, &cp, &res)
+
+	cp.prune(s.overlap)
+	if err := s.store.Save(ctx, s.name, cp); err != nil {
+		return res, errors.Join(pullErr, fmt.Errorf("save checkpoint: %w", err))
+	}
+
+	return res, pullErr
+}
+
+func (s *Syncer[T]) pull(ctx context.Context, opts bc.ListOptions, cp *Checkpoint, res *Result) error {
+	for record, err := range s.page.All(ctx, opts) {
+		if err != nil {
+			return fmt.Errorf("list records: %w", err)
+		}
+
+		id, modified := s.id(record), s.modified(record)
+		if cp.seen(id, modified) {
+			res.Skipped++
+			continue
+		}
+
+		if err := s.handler.Upsert(ctx, record); err != nil {
+			return fmt.Errorf("upsert %s: %w", id, err)
+		}
+		cp.add(id, modified)
+		res.Upserted++
+	}
+	return nil
+}
+
+// Reconcile reads the ids of all the records and deletes the ones that are known
+// by the Handler but no longer exist. Records that are not known are read and
+// upserted. Only the ids are listed, with the BaseFilter of the page.
+// The checkpoint of the pulls is not changed, except for LastReconciled.
+func (s *Syncer[T]) Reconcile(ctx context.Context) (Result, error) {
+	var res Result
+	started := time.Now()
+
+	ids, err := s.handler.IDs(ctx)
+	if err != nil {
+		return res, fmt.Errorf("get known ids: %w", err)
+	}
+
+	known := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+
+	s.logger.Debug("Reconciling records...", "name", s.name, "known", len(known))
+
+	// List only the ids, so the records are not validated as T
+	idPage := bc.NewAPIPage[idRecord](s.page.Client(), s.page.EntitySetName())
+	idPage.BaseFilter = s.page.BaseFilter
+
+	var unknown []uuid.UUID
+	opts := bc.ListOptions{Select: []string{"id"}, MaxPageSize: s.pageSize}
+	for record, err := range idPage.All(ctx, opts) {
+		if err != nil {
+			return res, fmt.Errorf("list ids: %w", err)
+		}
+
+		if known[record.ID] {
+			delete(known, record.ID)
+			res.Skipped++
+			continue
+		}
+		unknown = append(unknown, record.ID)
+	}
+
+	for _, id := range unknown {
+		record, err := s.page.Get(ctx, id, bc.GetOptions{})
+		if err != nil {
+			return res, fmt.Errorf("get %s: %w", id, err)
+		}
+		if err := s.handler.Upsert(ctx, record); err != nil {
+			return res, fmt.Errorf("upsert %s: %w", id, err)
+		}
+		res.Upserted++
+	}
+
+	// The remaining ids were not read so they were deleted
+	for id := range known {
+		if err := s.handler.Delete(ctx, id); err != nil {
+			return res, fmt.Errorf("delete %s: %w", id, err)
+		}
+		res.Deleted++
+	}
+
+	cp, err := s.store.Load(ctx, s.name)
+	if err != nil {
+		return res, fmt.Errorf("load checkpoint: %w", err)
+	}
+	cp.LastReconciled = started
+	if err := s.store.Save(ctx, s.name, cp); err != nil {
+		return res, fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	return res, nil
+}
+
+// idRecord is a record with only the id, used by Reconcile.
+type idRecord struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// Validate implements the bc.Validator interface.
+func (r idRecord) Validate() error {
+	if r.ID == uuid.Nil {
+		return errors.New("id is empty")
+	}
+	return nil
+}
+
+// Run pulls the changes every interval until the context is done, and reconciles
+// when the last reconciliation is older than reconcileInterval. Zero never
+// reconciles. Errors are logged and retried at the next interval.
+// It returns the error of the context.
+func (s *Syncer[T]) Run(ctx context.Context, interval, reconcileInterval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if res, err := s.Pull(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Error("Failed to pull changes.", "name", s.name, "error", err)
+		} else {
+			s.logger.Debug("Pulled changes.", "name", s.name, "upserted", res.Upserted, "skipped", res.Skipped)
+		}
+
+		if reconcileInterval > 0 {
+			if err := s.reconcileIfDue(ctx, reconcileInterval); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				s.logger.Error("Failed to reconcile records.", "name", s.name, "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Syncer[T]) reconcileIfDue(ctx context.Context, reconcileInterval time.Duration) error {
+	cp, err := s.store.Load(ctx, s.name)
+	if err != nil {
+		return fmt.Errorf("load checkpoint: %w", err)
+	}
+	if time.Since(cp.LastReconciled) < reconcileInterval {
+		return nil
+	}
+
+	res, err := s.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	s.logger.Debug("Reconciled records.", "name", s.name, "upserted", res.Upserted, "deleted", res.Deleted)
+	return nil
+}
@@ -0,0 +1,252 @@
+package sync_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/bc/sync"
+	"github.com/google/uuid"
+)
+
+type customer struct {
+	ID                   uuid.UUID `json:"id"`
+	Number               string    `json:"number"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+}
+
+func (c customer) Validate() error { return nil }
+
+// memoryHandler keeps the upserted records by id.
+type memoryHandler struct {
+	records map[uuid.UUID]customer
+	upserts []string
+	failOn  string
+}
+
+func (h *memoryHandler) Upsert(_ context.Context, c customer) error {
+	if c.Number == h.failOn {
+		return errors.New("fail")
+	}
+	h.records[c.ID] = c
+	h.upserts = append(h.upserts, c.Number)
+	return nil
+}
+
+func (h *memoryHandler) Delete(_ context.Context, id uuid.UUID) error {
+	delete(h.records, id)
+	return nil
+}
+
+func (h *memoryHandler) IDs(context.Context) ([]uuid.UUID, error) {
+	return slices.Collect(maps.Keys(h.records)), nil
+}
+
+func newSyncer(t *testing.T, srv *bcfake.Server, store sync.CheckpointStore, handler *memoryHandler) (*sync.Syncer[customer], *bc.APIPage[customer]) {
+	t.Helper()
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := bc.NewAPIPage[customer](client, "customers")
+
+	syncer := sync.New(page, store, sync.Handler[customer](handler),
+		func(c customer) uuid.UUID { return c.ID },
+		func(c customer) time.Time { return c.LastModifiedDateTime },
+		sync.WithPageSize(2),
+	)
+	return syncer, page
+}
+
+func TestSyncerPull(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	for _, c := range []customer{
+		{Number: "3", LastModifiedDateTime: base.Add(time.Second)},
+		{Number: "1", LastModifiedDateTime: base},
+		{Number: "2", LastModifiedDateTime: base},
+	} {
+		if _, err := srv.Insert("customers", c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := sync.NewMemoryStore()
+	handler := &memoryHandler{records: map[uuid.UUID]customer{}}
+	syncer, page := newSyncer(t, srv, store, handler)
+	ctx := context.Background()
+
+	res, err := syncer.Pull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Upserted != 3 {
+		t.Errorf("wanted 3 upserted, got %+v", res)
+	}
+	if handler.upserts[2] != "3" {
+		t.Errorf("wanted records in modified order, got %v", handler.upserts)
+	}
+
+	cp, _ := store.Load(ctx, "customers")
+	if !cp.LastModified.Equal(base.Add(time.Second)) {
+		t.Errorf("wanted last modified %s, got %s", base.Add(time.Second), cp.LastModified)
+	}
+
+	// Nothing changed so the records in the overlap window are skipped
+	res, err = syncer.Pull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Upserted != 0 || res.Skipped != 3 {
+		t.Errorf("wanted 3 skipped, got %+v", res)
+	}
+
+	// A change and a record committed late with an older timestamp are both pulled
+	var first customer
+	for _, c := range handler.records {
+		if c.Number == "1" {
+			first = c
+		}
+	}
+	if _, err := page.Update(ctx, first.ID, nil, map[string]any{"number": "1-changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Insert("customers", customer{Number: "late", LastModifiedDateTime: base.Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler.upserts = nil
+	res, err = syncer.Pull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Upserted != 2 || !slices.Equal(handler.upserts, []string{"late", "1-changed"}) {
+		t.Errorf("wanted late and 1-changed upserted, got %+v %v", res, handler.upserts)
+	}
+}
+
+func TestSyncerPullHandlerError(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	for i, number := range []string{"1", "2", "3"} {
+		c := customer{Number: number, LastModifiedDateTime: base.Add(time.Duration(i) * time.Second)}
+		if _, err := srv.Insert("customers", c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := sync.NewMemoryStore()
+	handler := &memoryHandler{records: map[uuid.UUID]customer{}, failOn: "2"}
+	syncer, _ := newSyncer(t, srv, store, handler)
+	ctx := context.Background()
+
+	if _, err := syncer.Pull(ctx); err == nil {
+		t.Fatal("wanted error from handler")
+	}
+
+	// The progress before the error is saved
+	cp, _ := store.Load(ctx, "customers")
+	if !cp.LastModified.Equal(base) {
+		t.Errorf("wanted last modified %s, got %s", base, cp.LastModified)
+	}
+
+	handler.failOn = ""
+	handler.upserts = nil
+	if _, err := syncer.Pull(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(handler.upserts, []string{"2", "3"}) {
+		t.Errorf("wanted 2 and 3 upserted, got %v", handler.upserts)
+	}
+}
+
+func TestSyncerReconcile(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	store := sync.NewMemoryStore()
+	handler := &memoryHandler{records: map[uuid.UUID]customer{}}
+	syncer, page := newSyncer(t, srv, store, handler)
+	ctx := context.Background()
+
+	var ids []uuid.UUID
+	for _, number := range []string{"1", "2"} {
+		id, err := srv.Insert("customers", map[string]any{"number": number})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := syncer.Pull(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := page.Delete(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not pulled yet, so it is read and upserted
+	id, err := srv.Insert("customers", map[string]any{"number": "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := syncer.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Deleted != 1 || res.Skipped != 1 || res.Upserted != 1 {
+		t.Errorf("wanted 1 deleted, 1 skipped and 1 upserted, got %+v", res)
+	}
+	if _, ok := handler.records[ids[0]]; ok {
+		t.Error("wanted deleted record to be removed")
+	}
+	if got := handler.records[id]; got.Number != "3" {
+		t.Errorf("wanted the full record to be upserted, got %+v", got)
+	}
+
+	cp, _ := store.Load(ctx, "customers")
+	if cp.LastReconciled.IsZero() || cp.LastModified.IsZero() {
+		t.Errorf("wanted last reconciled and last modified to be set, got %+v", cp)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := sync.NewFileStore(t.TempDir() + "/checkpoints")
+	ctx := context.Background()
+	name := "salesOrders(" + uuid.NewString() + ")/salesOrderLines"
+
+	cp, err := store.Load(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.LastModified.IsZero() {
+		t.Errorf("wanted zero checkpoint, got %+v", cp)
+	}
+
+	id := uuid.New()
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := sync.Checkpoint{LastModified: modified, Seen: map[uuid.UUID]time.Time{id: modified}}
+	if err := store.Save(ctx, name, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Load(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LastModified.Equal(modified) || !got.Seen[id].Equal(modified) {
+		t.Errorf("wanted %+v, got %+v", want, got)
+	}
+}
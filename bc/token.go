@@ -2,10 +2,18 @@ package bc
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/cache"
 	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/managedidentity"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // Auth is used to retrieve an AccessToken.
@@ -25,8 +33,68 @@ type TokenGetter interface {
 	GetToken(context.Context) (AccessToken, error)
 }
 
+// ExpiringTokenGetter is a TokenGetter that also returns when the
+// AccessToken expires. It is used by [CachingTokenGetter].
+type ExpiringTokenGetter interface {
+	TokenGetter
+	GetTokenWithExpiry(context.Context) (AccessToken, time.Time, error)
+}
+
+// AuthOption configures the MSAL client created by NewAuth and the other constructors.
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	cache         cache.ExportReplace
+	authorityHost string
+	scope         string
+	// sendX5C sends the certificate chain with the client assertion.
+	sendX5C bool
+}
+
+const (
+	// DefaultAuthorityHost is the Entra authority host used to get tokens.
+	DefaultAuthorityHost = "https://login.microsoft.com"
+	// DefaultScope is the scope for the BC online APIs.
+	DefaultScope = "https://api.businesscentral.dynamics.com/.default"
+)
+
+// WithAuthorityHost sets the Entra authority host instead of [DefaultAuthorityHost],
+// e.g. for sovereign clouds.
+func WithAuthorityHost(host string) AuthOption {
+	return func(o *authOptions) {
+		o.authorityHost = host
+	}
+}
+
+// WithScope sets the scope of the access token instead of [DefaultScope].
+func WithScope(scope string) AuthOption {
+	return func(o *authOptions) {
+		o.scope = scope
+	}
+}
+
+// newAuthOptions applies the options over the defaults.
+func newAuthOptions(opts []AuthOption) authOptions {
+	o := authOptions{
+		authorityHost: DefaultAuthorityHost,
+		scope:         DefaultScope,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTokenCache persists the MSAL token cache with the accessor, for example
+// a [FileTokenCache], so short-lived processes can reuse tokens.
+func WithTokenCache(accessor cache.ExportReplace) AuthOption {
+	return func(o *authOptions) {
+		o.cache = accessor
+	}
+}
+
 // NewAuth validates the AuthParams and creates a new AuthClient.
-func NewAuth(tenantID, clientID, clientSecret string) (*Auth, error) {
+func NewAuth(tenantID, clientID, clientSecret string, opts ...AuthOption) (*Auth, error) {
 
 	cred, err := confidential.NewCredFromSecret(clientSecret)
 	if err != nil {
@@ -34,17 +102,104 @@ func NewAuth(tenantID, clientID, clientSecret string) (*Auth, error) {
 		return nil, err
 	}
 
-	authority := "https://login.microsoft.com/" + string(tenantID)
+	return newAuth(tenantID, clientID, cred, opts...)
+}
+
+// NewAuthFromCertificate creates a new AuthClient that authenticates with a certificate
+// instead of a client secret. The certData can be PEM (containing the certificate and
+// private key) or PFX/PKCS#12. The password is used if the data is encrypted.
+// If the data has intermediate certificates, they are sent with the client certificate.
+func NewAuthFromCertificate(tenantID, clientID string, certData []byte, password string, opts ...AuthOption) (*Auth, error) {
+	certs, key, err := confidential.CertFromPEM(certData, password)
+	if err != nil {
+		// Not PEM, try PFX with the chain
+		pfxKey, pfxCert, caCerts, pfxErr := pkcs12.DecodeChain(certData, password)
+		if pfxErr != nil {
+			return nil, fmt.Errorf("authClient certificate: not PEM (%s) or PFX (%s)", err, pfxErr)
+		}
+		certs, key = append([]*x509.Certificate{pfxCert}, caCerts...), pfxKey
+	}
+
+	cred, err := confidential.NewCredFromCert(certs, key)
+	if err != nil {
+		return nil, fmt.Errorf("authClient certificate: %w", err)
+	}
+
+	if len(certs) > 1 {
+		opts = append(opts, func(o *authOptions) { o.sendX5C = true })
+	}
+
+	return newAuth(tenantID, clientID, cred, opts...)
+}
+
+// NewAuthFromCertificateFile reads a PEM or PFX certificate file and calls [NewAuthFromCertificate].
+func NewAuthFromCertificateFile(tenantID, clientID, path, password string, opts ...AuthOption) (*Auth, error) {
+	certData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authClient certificate: %w", err)
+	}
+
+	return NewAuthFromCertificate(tenantID, clientID, certData, password, opts...)
+}
+
+// AssertionFunc returns a signed client assertion (JWT), for example a federated token.
+type AssertionFunc func(context.Context) (string, error)
+
+// NewAuthFromAssertion creates a new AuthClient that authenticates with a client assertion
+// instead of a client secret. Use it for workload identity federation, where the assertion
+// is a token issued by a trusted identity provider. The assertion func is called whenever
+// a new access token is needed.
+func NewAuthFromAssertion(tenantID, clientID string, assertion AssertionFunc, opts ...AuthOption) (*Auth, error) {
+	if assertion == nil {
+		return nil, errors.New("authClient assertion: assertion func is nil")
+	}
+
+	cred := confidential.NewCredFromAssertionCallback(func(ctx context.Context, _ confidential.AssertionRequestOptions) (string, error) {
+		return assertion(ctx)
+	})
+
+	return newAuth(tenantID, clientID, cred, opts...)
+}
 
-	confidentialClient, err := confidential.New(authority, string(clientID), cred)
+// AssertionFromFile returns an [AssertionFunc] that reads the assertion from a file.
+// The file is read on every call because tokens like Kubernetes projected service
+// account tokens (AZURE_FEDERATED_TOKEN_FILE) or a saved GitHub OIDC token are rotated.
+func AssertionFromFile(path string) AssertionFunc {
+	return func(context.Context) (string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read assertion file: %w", err)
+		}
+
+		assertion := strings.TrimSpace(string(b))
+		if assertion == "" {
+			return "", fmt.Errorf("read assertion file: %s is empty", path)
+		}
+		return assertion, nil
+	}
+}
+
+// newAuth creates the confidential client for the credential.
+func newAuth(tenantID, clientID string, cred confidential.Credential, opts ...AuthOption) (*Auth, error) {
+	o := newAuthOptions(opts)
+
+	authority := strings.TrimSuffix(o.authorityHost, "/") + "/" + string(tenantID)
+
+	var clientOpts []confidential.Option
+	if o.cache != nil {
+		clientOpts = append(clientOpts, confidential.WithCache(o.cache))
+	}
+	if o.sendX5C {
+		clientOpts = append(clientOpts, confidential.WithX5C())
+	}
+
+	confidentialClient, err := confidential.New(authority, string(clientID), cred, clientOpts...)
 	if err != nil {
 		err = fmt.Errorf("authclient confidentialClient: %w", err)
 		return nil, err
 	}
 
-	// Don't think there is any reason to use a different one.
-	// Can have this as a config param if ever need to.
-	scopes := []string{"https://api.businesscentral.dynamics.com/.default"}
+	scopes := []string{o.scope}
 
 	return &Auth{
 		client: confidentialClient,
@@ -55,6 +210,12 @@ func NewAuth(tenantID, clientID, clientSecret string) (*Auth, error) {
 }
 
 func (ac *Auth) GetToken(ctx context.Context) (AccessToken, error) {
+	token, _, err := ac.GetTokenWithExpiry(ctx)
+	return token, err
+}
+
+// GetTokenWithExpiry implements the ExpiringTokenGetter interface.
+func (ac *Auth) GetTokenWithExpiry(ctx context.Context) (AccessToken, time.Time, error) {
 
 	ac.logger.Debug("Acquiring token...")
 	result, err := ac.client.AcquireTokenSilent(ctx, ac.scopes)
@@ -64,9 +225,56 @@ func (ac *Auth) GetToken(ctx context.Context) (AccessToken, error) {
 
 		result, err = ac.client.AcquireTokenByCredential(ctx, ac.scopes)
 		if err != nil {
-			return "", fmt.Errorf("error getting access token: %w", err)
+			return "", time.Time{}, fmt.Errorf("error getting access token: %w", err)
 		}
 	}
 	ac.logger.Debug("Successfully acquired token.")
-	return AccessToken(result.AccessToken), nil
+	return AccessToken(result.AccessToken), result.ExpiresOn, nil
+}
+
+// ManagedIdentityAuth is used to retrieve an AccessToken with the Azure
+// managed identity of the host. Implements the TokenGetter interface.
+type ManagedIdentityAuth struct {
+	client   managedidentity.Client
+	resource string
+	logger   *slog.Logger
+}
+
+// NewManagedIdentityAuth creates a new ManagedIdentityAuth. If clientID is empty
+// the system-assigned identity is used, otherwise the user-assigned identity
+// with that client ID. Only the WithScope option is used.
+func NewManagedIdentityAuth(clientID string, opts ...AuthOption) (*ManagedIdentityAuth, error) {
+	o := newAuthOptions(opts)
+
+	id := managedidentity.SystemAssigned()
+	if clientID != "" {
+		id = managedidentity.UserAssignedClientID(clientID)
+	}
+
+	client, err := managedidentity.New(id)
+	if err != nil {
+		return nil, fmt.Errorf("authclient managedIdentity: %w", err)
+	}
+
+	return &ManagedIdentityAuth{
+		client:   client,
+		resource: strings.TrimSuffix(o.scope, "/.default"),
+		logger:   slog.Default(),
+	}, nil
+}
+
+func (mi *ManagedIdentityAuth) GetToken(ctx context.Context) (AccessToken, error) {
+	token, _, err := mi.GetTokenWithExpiry(ctx)
+	return token, err
+}
+
+// GetTokenWithExpiry implements the ExpiringTokenGetter interface.
+func (mi *ManagedIdentityAuth) GetTokenWithExpiry(ctx context.Context) (AccessToken, time.Time, error) {
+	mi.logger.Debug("Acquiring managed identity token...")
+	result, err := mi.client.AcquireToken(ctx, mi.resource)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error getting access token: %w", err)
+	}
+	mi.logger.Debug("Successfully acquired token.")
+	return AccessToken(result.AccessToken), result.ExpiresOn, nil
 }
@@ -1,9 +1,20 @@
 package bc_test
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/erlorenz/bc-go/bc"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 func TestNewAuthClient(t *testing.T) {
@@ -42,3 +53,158 @@ func TestNewAuthClient(t *testing.T) {
 	}
 
 }
+
+// newTestCertPEM creates a self-signed certificate and private key in PEM format.
+func newTestCertPEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bc-go test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return append(certPEM, keyPEM...)
+}
+
+func TestNewAuthFromCertificate(t *testing.T) {
+	certPEM := newTestCertPEM(t)
+
+	if _, err := bc.NewAuthFromCertificate(validGUID, validGUID, certPEM, ""); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if _, err := bc.NewAuthFromCertificate(validGUID, validGUID, []byte("not a cert"), ""); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bc.NewAuthFromCertificateFile(validGUID, validGUID, path, ""); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
+
+// newTestCertPFX creates a certificate signed by an intermediate CA and encodes it
+// with the chain as a modern (AES-256, SHA-256) PFX.
+func newTestCertPFX(t *testing.T, password string) []byte {
+	t.Helper()
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bc-go test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "bc-go test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pfx, err := pkcs12.Modern.Encode(key, cert, []*x509.Certificate{caCert}, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pfx
+}
+
+func TestNewAuthFromCertificatePFXChain(t *testing.T) {
+	pfx := newTestCertPFX(t, "secret")
+
+	if _, err := bc.NewAuthFromCertificate(validGUID, validGUID, pfx, "secret"); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	if _, err := bc.NewAuthFromCertificate(validGUID, validGUID, pfx, "wrong"); err == nil {
+		t.Error("expected error for the wrong password, got nil")
+	}
+}
+
+func TestAssertionFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	assertion := bc.AssertionFromFile(path)
+
+	got, err := assertion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "first" {
+		t.Errorf("wanted %q, got %q", "first", got)
+	}
+
+	// The file is read again on each call
+	if err := os.WriteFile(path, []byte("second"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = assertion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "second" {
+		t.Errorf("wanted %q, got %q", "second", got)
+	}
+
+	if _, err := bc.NewAuthFromAssertion(validGUID, validGUID, assertion); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
+
+func TestNewAuthOptions(t *testing.T) {
+	_, err := bc.NewAuth(validGUID, validGUID, "TEST",
+		bc.WithAuthorityHost("https://login.microsoftonline.us/"),
+		bc.WithScope("https://api.businesscentral.dynamics.us/.default"),
+	)
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
@@ -0,0 +1,268 @@
+package bc
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/cache"
+)
+
+// defaultTokenLifetime is used when the expiry of a token is unknown.
+const defaultTokenLifetime = 5 * time.Minute
+
+// CachingTokenGetter wraps a TokenGetter and caches the AccessToken until it expires.
+// When the token is within the refresh window it is refreshed in the background
+// while the current token is still returned. Concurrent refreshes are deduplicated,
+// so a burst of requests only results in one call to the wrapped TokenGetter.
+// Implements the TokenGetter interface.
+type CachingTokenGetter struct {
+	getter        TokenGetter
+	refreshBefore time.Duration
+	logger        *slog.Logger
+
+	mu       sync.Mutex
+	token    AccessToken
+	expires  time.Time
+	inflight *tokenCall
+}
+
+// tokenCall is a refresh in progress. done is closed when it completes.
+type tokenCall struct {
+	done    chan struct{}
+	token   AccessToken
+	expires time.Time
+	err     error
+}
+
+// NewCachingTokenGetter creates a [CachingTokenGetter] that refreshes the token
+// refreshBefore it expires. The expiry is taken from an [ExpiringTokenGetter], or
+// the "exp" claim of a JWT, or defaults to 5 minutes.
+func NewCachingTokenGetter(getter TokenGetter, refreshBefore time.Duration) *CachingTokenGetter {
+	if getter == nil {
+		panic("create caching token getter: getter is nil")
+	}
+
+	return &CachingTokenGetter{
+		getter:        getter,
+		refreshBefore: refreshBefore,
+		logger:        slog.Default(),
+	}
+}
+
+// setLogger sets the logger, e.g. to the one of the Client that uses it.
+func (c *CachingTokenGetter) setLogger(logger *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logger
+}
+
+// GetToken returns the cached token, refreshing it if needed.
+func (c *CachingTokenGetter) GetToken(ctx context.Context) (AccessToken, error) {
+	c.mu.Lock()
+	now := time.Now()
+
+	// Still fresh
+	if c.token != "" && now.Before(c.expires.Add(-c.refreshBefore)) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	// Still valid but due for a refresh, refresh in the background
+	if c.token != "" && now.Before(c.expires) {
+		token := c.token
+		if c.inflight == nil {
+			c.logger.Debug("Refreshing token in the background.", "expires", c.expires)
+			c.startRefresh(context.WithoutCancel(ctx), true)
+		}
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	// Expired or missing, wait for the refresh. It is shared with the other
+	// callers, so it is not canceled with the context of this one.
+	call := c.inflight
+	if call == nil {
+		call = c.startRefresh(context.WithoutCancel(ctx), false)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// startRefresh starts getting a new token. The error of a background refresh is
+// logged, as no caller waits for it. Must be called with the lock held.
+func (c *CachingTokenGetter) startRefresh(ctx context.Context, background bool) *tokenCall {
+	call := &tokenCall{done: make(chan struct{})}
+	c.inflight = call
+
+	go func() {
+		call.token, call.expires, call.err = getTokenWithExpiry(ctx, c.getter)
+
+		c.mu.Lock()
+		if call.err == nil {
+			c.token, c.expires = call.token, call.expires
+		} else if background {
+			c.logger.Warn("Failed to refresh token in the background.", "expires", c.expires, "error", call.err)
+		}
+		c.inflight = nil
+		c.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	return call
+}
+
+// getTokenWithExpiry gets a token from the getter and works out when it expires.
+func getTokenWithExpiry(ctx context.Context, getter TokenGetter) (AccessToken, time.Time, error) {
+	if eg, ok := getter.(ExpiringTokenGetter); ok {
+		return eg.GetTokenWithExpiry(ctx)
+	}
+
+	token, err := getter.GetToken(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if expires, ok := jwtExpiry(token); ok {
+		return token, expires, nil
+	}
+	return token, time.Now().Add(defaultTokenLifetime), nil
+}
+
+// jwtExpiry reads the "exp" claim of a JWT without verifying it.
+func jwtExpiry(token AccessToken) (time.Time, bool) {
+	parts := strings.Split(string(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Exp, 0), true
+}
+
+// FileTokenCache persists the MSAL token cache to a file encrypted with AES-GCM.
+// Pass it to NewAuth with [WithTokenCache] so short-lived processes, like CLI
+// invocations, reuse tokens instead of requesting a new one each time.
+// Implements the MSAL cache.ExportReplace interface.
+type FileTokenCache struct {
+	path string
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+// NewFileTokenCache creates a [FileTokenCache] at path. The key must be
+// 32 bytes (AES-256) and kept secret, for example in an OS keychain.
+func NewFileTokenCache(path string, key []byte) (*FileTokenCache, error) {
+	if path == "" {
+		return nil, errors.New("file token cache: path is empty")
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("file token cache: key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("file token cache: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("file token cache: %w", err)
+	}
+
+	return &FileTokenCache{path: path, aead: aead}, nil
+}
+
+// Replace reads and decrypts the file into the MSAL cache. A missing file is an empty cache.
+func (f *FileTokenCache) Replace(ctx context.Context, c cache.Unmarshaler, hints cache.ReplaceHints) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read token cache: %w", err)
+	}
+
+	nonceSize := f.aead.NonceSize()
+	if len(data) < nonceSize {
+		return errors.New("read token cache: file is too short")
+	}
+
+	plain, err := f.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return fmt.Errorf("decrypt token cache: %w", err)
+	}
+
+	return c.Unmarshal(plain)
+}
+
+// Export encrypts the MSAL cache and writes it to the file.
+func (f *FileTokenCache) Export(ctx context.Context, c cache.Marshaler, hints cache.ExportHints) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	plain, err := c.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshal token cache: %w", err)
+	}
+
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("encrypt token cache: %w", err)
+	}
+	data := f.aead.Seal(nonce, nonce, plain, nil)
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write token cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write token cache: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("write token cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package bc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/cache"
+	"github.com/erlorenz/bc-go/bc"
+)
+
+// countingTokenGetter returns a new token on every call that expires after lifetime.
+type countingTokenGetter struct {
+	calls    atomic.Int32
+	lifetime time.Duration
+	delay    time.Duration
+}
+
+func (g *countingTokenGetter) GetToken(ctx context.Context) (bc.AccessToken, error) {
+	token, _, err := g.GetTokenWithExpiry(ctx)
+	return token, err
+}
+
+func (g *countingTokenGetter) GetTokenWithExpiry(context.Context) (bc.AccessToken, time.Time, error) {
+	n := g.calls.Add(1)
+	time.Sleep(g.delay)
+	return bc.AccessToken(fmt.Sprintf("token-%d", n)), time.Now().Add(g.lifetime), nil
+}
+
+func TestCachingTokenGetterSingleFlight(t *testing.T) {
+	getter := &countingTokenGetter{lifetime: time.Hour, delay: 10 * time.Millisecond}
+	tg := bc.NewCachingTokenGetter(getter, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			token, err := tg.GetToken(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			if token != "token-1" {
+				t.Errorf("wanted token-1, got %s", token)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := getter.calls.Load(); got != 1 {
+		t.Errorf("wanted 1 call, got %d", got)
+	}
+}
+
+func TestCachingTokenGetterBackgroundRefresh(t *testing.T) {
+	// Every token is already inside the refresh window
+	getter := &countingTokenGetter{lifetime: time.Hour}
+	tg := bc.NewCachingTokenGetter(getter, 2*time.Hour)
+	ctx := context.Background()
+
+	token, err := tg.GetToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" {
+		t.Fatalf("wanted token-1, got %s", token)
+	}
+
+	// Returns the current token while refreshing
+	token, err = tg.GetToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" {
+		t.Errorf("wanted token-1 during refresh, got %s", token)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for getter.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	token, err = tg.GetToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "token-1" {
+		t.Error("expected refreshed token, got token-1")
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCachingTokenGetterBackgroundRefreshError(t *testing.T) {
+	var calls atomic.Int32
+	getter := tokenGetterFunc(func(context.Context) (bc.AccessToken, error) {
+		if calls.Add(1) > 1 {
+			return "", errors.New("credential revoked")
+		}
+		return "token", nil
+	})
+
+	// The default lifetime is 5 minutes, so every token is due for a refresh
+	tg := bc.NewCachingTokenGetter(getter, time.Hour)
+
+	// The Client logs the refreshes
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	if _, err := bc.NewClient(fakeConfig, bc.WithAuthClient(tg), bc.WithLogger(logger)); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := tg.GetToken(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(logs.String(), "credential revoked") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := logs.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, "credential revoked") {
+		t.Errorf("wanted the refresh error logged as a warning, got %q", got)
+	}
+}
+
+func TestCachingTokenGetterJWTExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, `{"exp":%d}`, time.Now().Add(-time.Minute).Unix()))
+	jwt := bc.AccessToken("header." + payload + ".signature")
+
+	var calls atomic.Int32
+	getter := tokenGetterFunc(func(context.Context) (bc.AccessToken, error) {
+		calls.Add(1)
+		return jwt, nil
+	})
+	tg := bc.NewCachingTokenGetter(getter, 0)
+
+	// The token is already expired so every call gets a new one
+	for range 2 {
+		if _, err := tg.GetToken(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("wanted 2 calls, got %d", got)
+	}
+}
+
+func TestCachingTokenGetterCanceledCaller(t *testing.T) {
+	release := make(chan struct{})
+	getter := tokenGetterFunc(func(ctx context.Context) (bc.AccessToken, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "token", nil
+	})
+	tg := bc.NewCachingTokenGetter(getter, time.Minute)
+
+	// The first caller starts the refresh and gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := tg.GetToken(ctx)
+		errc <- err
+	}()
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("wanted context canceled, got %v", err)
+	}
+
+	// The refresh still completes for the next caller
+	close(release)
+	token, err := tg.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" {
+		t.Errorf("wanted token, got %s", token)
+	}
+}
+
+type tokenGetterFunc func(context.Context) (bc.AccessToken, error)
+
+func (f tokenGetterFunc) GetToken(ctx context.Context) (bc.AccessToken, error) {
+	return f(ctx)
+}
+
+// memoryCache implements the MSAL cache Marshaler and Unmarshaler.
+type memoryCache struct {
+	data []byte
+}
+
+func (m *memoryCache) Marshal() ([]byte, error) { return m.data, nil }
+
+func (m *memoryCache) Unmarshal(b []byte) error {
+	m.data = b
+	return nil
+}
+
+func TestFileTokenCache(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.bin")
+	key := bytes.Repeat([]byte{7}, 32)
+
+	fc, err := bc.NewFileTokenCache(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing file is an empty cache
+	empty := &memoryCache{}
+	if err := fc.Replace(ctx, empty, cache.ReplaceHints{}); err != nil {
+		t.Fatal(err)
+	}
+	if empty.data != nil {
+		t.Errorf("wanted empty cache, got %s", empty.data)
+	}
+
+	want := []byte(`{"AccessToken":{}}`)
+	if err := fc.Export(ctx, &memoryCache{data: want}, cache.ExportHints{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &memoryCache{}
+	if err := fc.Replace(ctx, got, cache.ReplaceHints{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.data, want) {
+		t.Errorf("wanted %s, got %s", want, got.data)
+	}
+
+	// Wrong key cannot decrypt
+	other, err := bc.NewFileTokenCache(path, bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Replace(ctx, &memoryCache{}, cache.ReplaceHints{}); err == nil {
+		t.Error("expected error with wrong key, got nil")
+	}
+
+	if _, err := bc.NewFileTokenCache(path, []byte("short")); err == nil {
+		t.Error("expected error for short key, got nil")
+	}
+}
@@ -1,24 +1,38 @@
 package bc
 
 import (
+	"cmp"
 	"fmt"
 	"net/url"
+	"path"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
-// dksdlfjdsja
+// DefaultAPIHost is the scheme and host of the BC online APIs.
+const DefaultAPIHost = "https://api.businesscentral.dynamics.com"
+
 // BuildBaseURL builds the BaseURL from the ClientConfig.
 // It uses the structure
 // "https://api.businesscentral.dynamics.com/v2.0/{tenantID}/{environment}/api/{APIendpoint}/companies({companyID})"
+// or, if ServerInstance is set, the on-premises structure
+// "{APIHost}/{serverInstance}/api/{APIendpoint}/companies({companyID})".
 func BuildBaseURL(cfg ClientConfig) (*url.URL, error) {
 
-	// All BC APIs use this same prefix.
-	staticPrefix := "https://api.businesscentral.dynamics.com/v2.0"
+	// All BC APIs on the host use this same prefix.
+	host := strings.TrimSuffix(cmp.Or(cfg.APIHost, DefaultAPIHost), "/")
+	staticPrefix := host + "/v2.0"
 
 	// Specific to this Client
 	dynamicURL := fmt.Sprintf("/%s/%s/api/%s/companies(%s)", cfg.TenantID, cfg.Environment, cfg.APIEndpoint, cfg.CompanyID)
 
+	// On-premises has no tenant or environment in the path
+	if cfg.ServerInstance != "" {
+		staticPrefix = host
+		dynamicURL = fmt.Sprintf("/%s/api/%s/companies(%s)", cfg.ServerInstance, cfg.APIEndpoint, cfg.CompanyID)
+	}
+
 	// Final combination
 	baseURLstring := staticPrefix + dynamicURL
 	baseURL, err := url.Parse(baseURLstring)
@@ -57,6 +71,24 @@ func BuildRequestURL(baseURL url.URL, entitySet string, recordID uuid.UUID, quer
 	return newURL
 }
 
+// requestURL builds the URL with BuildRequestURL and adds the Path, if any.
+func (opts RequestOptions) requestURL(baseURL url.URL) url.URL {
+	newURL := BuildRequestURL(baseURL, opts.EntitySetName, opts.RecordID, opts.QueryParams)
+	if opts.Path != "" {
+		newURL.Path += "/" + strings.TrimPrefix(opts.Path, "/")
+	}
+	return newURL
+}
+
+// apiRootURL returns the base URL without the companies({companyID}) segment.
+// It uses the structure
+// "https://api.businesscentral.dynamics.com/v2.0/{tenantID}/{environment}/api/{APIendpoint}"
+func (c *Client) apiRootURL() url.URL {
+	rootURL := *c.baseURL
+	rootURL.Path = path.Dir(rootURL.Path)
+	return rootURL
+}
+
 // const pathIndexTenant = 2
 // const pathIndexEnvironment = 3
 // const pathIndexPublisher = 5
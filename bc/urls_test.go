@@ -63,3 +63,43 @@ func TestBuildBaseURLExt(t *testing.T) {
 		}
 	})
 }
+
+func TestBuildBaseURLAPIHost(t *testing.T) {
+	config := bc.ClientConfig{
+		TenantID:    validGUID,
+		Environment: "TEST",
+		APIEndpoint: "v2.0",
+		CompanyID:   validGUID,
+		APIHost:     "http://127.0.0.1:8080/",
+	}
+	url, err := bc.BuildBaseURL(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf("http://127.0.0.1:8080/v2.0/%s/TEST/api/v2.0/companies(%s)", validGUID, validGUID)
+	got := url.String()
+	if want != got {
+		t.Errorf("wanted %s, got %s", want, got)
+	}
+}
+
+func TestBuildBaseURLOnPremises(t *testing.T) {
+	config := bc.ClientConfig{
+		TenantID:       validGUID,
+		APIEndpoint:    "publisher/group/version",
+		CompanyID:      validGUID,
+		APIHost:        "https://server:7048",
+		ServerInstance: "BC",
+	}
+	url, err := bc.BuildBaseURL(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf("https://server:7048/BC/api/publisher/group/version/companies(%s)", validGUID)
+	got := url.String()
+	if want != got {
+		t.Errorf("wanted %s, got %s", want, got)
+	}
+}
@@ -3,6 +3,7 @@ package bc
 import (
 	"errors"
 	"fmt"
+	"net/url"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -48,3 +49,15 @@ func stringNotEmpty(s string) error {
 	}
 	return nil
 }
+
+// validateHost checks that s is an absolute URL with a scheme and host.
+func validateHost(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q must have a scheme and host", s)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/google/uuid"
+)
+
+// Fetch gets the record of a created or updated notification with the page. The page
+// must be for the entity set of the notification. If the notification is for another
+// company, the record is read from that company with the same Client.
+func Fetch[T bc.Validator](ctx context.Context, page *bc.APIPage[T], n Notification) (T, error) {
+	var v T
+
+	if n.ChangeType != ChangeCreated && n.ChangeType != ChangeUpdated {
+		return v, fmt.Errorf("fetch notification: cannot fetch change type %q", n.ChangeType)
+	}
+
+	id := n.RecordID()
+	if id == uuid.Nil {
+		return v, fmt.Errorf("fetch notification: no record id in resource %q", n.Resource)
+	}
+
+	page, err := pageFor(page, n)
+	if err != nil {
+		return v, err
+	}
+
+	return page.Get(ctx, id, bc.GetOptions{})
+}
+
+// FetchCollection returns an iterator over the records that changed in a collection
+// notification, using the $filter of its resource. Like Fetch, the page must be for the
+// entity set of the notification.
+func FetchCollection[T bc.Validator](ctx context.Context, page *bc.APIPage[T], n Notification) iter.Seq2[T, error] {
+	if n.ChangeType != ChangeCollection {
+		return fail[T](fmt.Errorf("fetch notification: change type %q is not %q", n.ChangeType, ChangeCollection))
+	}
+
+	page, err := pageFor(page, n)
+	if err != nil {
+		return fail[T](err)
+	}
+
+	return page.All(ctx, bc.ListOptions{Filter: n.Filter()})
+}
+
+// pageFor checks the entity set and returns a page for the company of the notification.
+func pageFor[T bc.Validator](page *bc.APIPage[T], n Notification) (*bc.APIPage[T], error) {
+	if page == nil {
+		return nil, errors.New("fetch notification: page is nil")
+	}
+
+	if n.EntitySetName() != page.EntitySetName() {
+		return nil, fmt.Errorf("fetch notification: resource %q is not for entity set %q", n.Resource, page.EntitySetName())
+	}
+
+	companyID := n.CompanyID()
+	if companyID == uuid.Nil || companyID == page.Client().CompanyID() {
+		return page, nil
+	}
+
+	companyPage := bc.NewAPIPage[T](page.Client().WithCompany(companyID), page.EntitySetName())
+	companyPage.BaseFilter = page.BaseFilter
+	companyPage.BaseExpand = page.BaseExpand
+	return companyPage, nil
+}
+
+// fail returns an iterator that yields the error.
+func fail[T any](err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var v T
+		yield(v, err)
+	}
+}
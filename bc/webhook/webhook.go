@@ -0,0 +1,245 @@
+// Package webhook receives the notifications of BC webhook subscriptions.
+// The subscriptions are managed with [bc.Subscriptions].
+//
+//	h := webhook.NewHandler(clientState, func(ctx context.Context, n webhook.Notification) error {
+//		switch n.ChangeType {
+//		case webhook.ChangeCollection:
+//			// Too many changes, read all the records in n.Filter()
+//		case webhook.ChangeDeleted:
+//			// n.RecordID() was deleted
+//		default:
+//			customer, err := webhook.Fetch(ctx, customers, n)
+//		}
+//		return nil
+//	})
+//	http.Handle("/bc/notifications", h)
+//
+// The callback is called while BC waits for the response, so it should be quick
+// and hand over longer work. If it returns an error the response is 500 and BC
+// sends the notifications again later.
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeType is the kind of change of a Notification.
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+	// ChangeCollection is sent instead of the single changes when there were too
+	// many. The Resource has a $filter for the records that changed, see Filter.
+	ChangeCollection ChangeType = "collection"
+)
+
+// Notification is a change of a record, or of a collection of records, of a subscription.
+type Notification struct {
+	SubscriptionID       string     `json:"subscriptionId"`
+	ClientState          string     `json:"clientState"`
+	ExpirationDateTime   time.Time  `json:"expirationDateTime"`
+	Resource             string     `json:"resource"`
+	ChangeType           ChangeType `json:"changeType"`
+	LastModifiedDateTime time.Time  `json:"lastModifiedDateTime"`
+}
+
+// notificationBatch is the body of a notification request.
+type notificationBatch struct {
+	Value []Notification `json:"value"`
+}
+
+// EntitySetName returns the entity set of the Resource, e.g. "customers".
+func (n Notification) EntitySetName() string {
+	name, _, _ := strings.Cut(n.lastSegment(), "(")
+	return name
+}
+
+// RecordID returns the id of the record of the Resource. It is
+// uuid.Nil for a collection or if it cannot be parsed.
+func (n Notification) RecordID() uuid.UUID {
+	_, id := parseSegment(n.lastSegment())
+	return id
+}
+
+// CompanyID returns the id of the company of the Resource, or uuid.Nil if it has none.
+func (n Notification) CompanyID() uuid.UUID {
+	for _, seg := range n.segments() {
+		if name, id := parseSegment(seg); name == "companies" {
+			return id
+		}
+	}
+	return uuid.Nil
+}
+
+// Filter returns the $filter of the Resource of a collection notification,
+// which selects the records that changed. It is empty if there is none.
+func (n Notification) Filter() string {
+	_, rawQuery, _ := strings.Cut(n.Resource, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	return query.Get("$filter")
+}
+
+// segments returns the path segments of the Resource without the query.
+func (n Notification) segments() []string {
+	path, _, _ := strings.Cut(n.Resource, "?")
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func (n Notification) lastSegment() string {
+	segments := n.segments()
+	return segments[len(segments)-1]
+}
+
+// parseSegment splits "name(id)" into the name and id.
+func parseSegment(seg string) (string, uuid.UUID) {
+	name, rest, ok := strings.Cut(seg, "(")
+	if !ok {
+		return seg, uuid.Nil
+	}
+	id, _ := uuid.Parse(strings.Trim(strings.TrimSuffix(rest, ")"), "'"))
+	return name, id
+}
+
+// Func is called with each Notification of a request.
+type Func func(ctx context.Context, n Notification) error
+
+// Option configures a [Handler].
+type Option func(*Handler)
+
+// DefaultMaxBodySize is the largest notification request body the [Handler] reads.
+const DefaultMaxBodySize = 4 << 20
+
+// WithMaxBodySize sets the largest request body instead of [DefaultMaxBodySize].
+// Larger requests are rejected with 413.
+func WithMaxBodySize(n int64) Option {
+	return func(h *Handler) {
+		h.maxBodySize = n
+	}
+}
+
+// WithoutClientState turns off the check of the clientState, e.g. when the
+// endpoint is authenticated another way. The clientState of NewHandler is ignored.
+func WithoutClientState() Option {
+	return func(h *Handler) {
+		h.skipClientState = true
+	}
+}
+
+// WithLogger sets a [slog.Logger] instead of the default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// Handler is an http.Handler that implements the BC webhook contract.
+type Handler struct {
+	clientState     string
+	skipClientState bool
+	fn              Func
+	maxBodySize     int64
+	logger          *slog.Logger
+}
+
+// NewHandler creates a [Handler] that calls fn with each notification. The clientState
+// must match the ClientState of the subscriptions; notifications with another one
+// are rejected. It panics if fn is nil, or if the clientState is empty without
+// the [WithoutClientState] option.
+func NewHandler(clientState string, fn Func, opts ...Option) *Handler {
+	if fn == nil {
+		panic("create webhook handler: fn is nil")
+	}
+
+	h := &Handler{
+		clientState: clientState,
+		fn:          fn,
+		maxBodySize: DefaultMaxBodySize,
+		logger:      slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.clientState == "" && !h.skipClientState {
+		panic("create webhook handler: clientState is empty, use WithoutClientState to not check it")
+	}
+	return h
+}
+
+// ServeHTTP responds to the validation request of a new or renewed subscription by
+// echoing the validationToken. Otherwise it decodes the notifications, checks
+// their clientState and calls the Func with each of them.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Handshake when the subscription is created or renewed
+	if token := r.URL.Query().Get("validationToken"); token != "" {
+		h.logger.Debug("Validating webhook subscription.")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(token))
+		return
+	}
+
+	// The endpoint is public, so limit what is read before the clientState is checked
+	var batch notificationBatch
+	body := http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	if err := json.NewDecoder(body).Decode(&batch); err != nil {
+		h.logger.Debug("Failed to decode notifications.", "error", err)
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "notifications too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("invalid notifications: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Reject the whole request if any notification is not from BC
+	for _, n := range batch.Value {
+		if !h.verify(n) {
+			h.logger.Warn("Rejected notification with an invalid clientState.", "subscriptionId", n.SubscriptionID)
+			http.Error(w, "invalid clientState", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	for _, n := range batch.Value {
+		if err := h.fn(r.Context(), n); err != nil {
+			h.logger.Error("Failed to handle notification.", "subscriptionId", n.SubscriptionID,
+				"resource", n.Resource, "changeType", n.ChangeType, "error", err)
+			http.Error(w, "failed to handle notification", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify compares the clientState in constant time.
+func (h *Handler) verify(n Notification) bool {
+	if h.skipClientState {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(n.ClientState), []byte(h.clientState)) == 1
+}
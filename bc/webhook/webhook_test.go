@@ -0,0 +1,225 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/bcfake"
+	"github.com/erlorenz/bc-go/bc/webhook"
+	"github.com/google/uuid"
+)
+
+type customer struct {
+	ID     uuid.UUID `json:"id"`
+	Number string    `json:"number"`
+}
+
+func (c customer) Validate() error { return nil }
+
+func TestNotificationResource(t *testing.T) {
+	companyID := uuid.New()
+	recordID := uuid.New()
+
+	tests := []struct {
+		name          string
+		resource      string
+		entitySetName string
+		recordID      uuid.UUID
+		filter        string
+	}{
+		{"record", "api/v2.0/companies(" + companyID.String() + ")/customers(" + recordID.String() + ")", "customers", recordID, ""},
+		{"leading slash", "/api/contoso/app/v1.0/companies(" + companyID.String() + ")/items(" + recordID.String() + ")", "items", recordID, ""},
+		{"collection", "api/v2.0/companies(" + companyID.String() + ")/customers?$filter=lastModifiedDateTime%20gt%202024-01-01T00:00:00Z", "customers", uuid.Nil, "lastModifiedDateTime gt 2024-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := webhook.Notification{Resource: tt.resource}
+			if got := n.EntitySetName(); got != tt.entitySetName {
+				t.Errorf("wanted entity set %s, got %s", tt.entitySetName, got)
+			}
+			if got := n.RecordID(); got != tt.recordID {
+				t.Errorf("wanted record id %s, got %s", tt.recordID, got)
+			}
+			if got := n.CompanyID(); got != companyID {
+				t.Errorf("wanted company id %s, got %s", companyID, got)
+			}
+			if got := n.Filter(); got != tt.filter {
+				t.Errorf("wanted filter %q, got %q", tt.filter, got)
+			}
+		})
+	}
+}
+
+func TestHandlerValidationToken(t *testing.T) {
+	h := webhook.NewHandler("secret", func(context.Context, webhook.Notification) error {
+		t.Error("did not expect a notification")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/?validationToken=abc%20123", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "abc 123" {
+		t.Errorf("wanted 200 with the token, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlerClientState(t *testing.T) {
+	called := false
+	h := webhook.NewHandler("secret", func(context.Context, webhook.Notification) error {
+		called = true
+		return nil
+	})
+
+	body := `{"value":[{"subscriptionId":"1","clientState":"wrong","resource":"customers","changeType":"updated"}]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("wanted 401, got %d", w.Code)
+	}
+	if called {
+		t.Error("did not expect the notification to be handled")
+	}
+}
+
+func TestHandlerRequiresClientState(t *testing.T) {
+	fn := func(context.Context, webhook.Notification) error { return nil }
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for an empty clientState, got nil")
+			}
+		}()
+		webhook.NewHandler("", fn)
+	}()
+
+	// Opting out accepts any clientState
+	h := webhook.NewHandler("", fn, webhook.WithoutClientState())
+	body := `{"value":[{"subscriptionId":"1","clientState":"any","resource":"customers","changeType":"updated"}]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("wanted 202, got %d", w.Code)
+	}
+}
+
+func TestHandlerMaxBodySize(t *testing.T) {
+	h := webhook.NewHandler("secret", func(context.Context, webhook.Notification) error {
+		t.Error("did not expect a notification")
+		return nil
+	}, webhook.WithMaxBodySize(64))
+
+	body := `{"value":[{"subscriptionId":"1","clientState":"secret","resource":"` + strings.Repeat("x", 100) + `"}]}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("wanted 413, got %d", w.Code)
+	}
+}
+
+func TestHandlerWithServer(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	customers := bc.NewAPIPage[customer](client, "customers")
+	ctx := context.Background()
+
+	id, err := srv.Insert("customers", customer{Number: "10000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetched []string
+	var failWith error
+	h := webhook.NewHandler("secret", func(ctx context.Context, n webhook.Notification) error {
+		if failWith != nil {
+			return failWith
+		}
+
+		switch n.ChangeType {
+		case webhook.ChangeCollection:
+			for c, err := range webhook.FetchCollection(ctx, customers, n) {
+				if err != nil {
+					return err
+				}
+				fetched = append(fetched, c.Number)
+			}
+		default:
+			c, err := webhook.Fetch(ctx, customers, n)
+			if err != nil {
+				return err
+			}
+			fetched = append(fetched, c.Number)
+		}
+		return nil
+	})
+	receiver := httptest.NewServer(h)
+	defer receiver.Close()
+
+	resource := client.SubscriptionResource("customers")
+	if _, err := client.Subscriptions().Create(ctx, bc.SubscriptionRequest{
+		NotificationURL: receiver.URL,
+		Resource:        resource,
+		ClientState:     "secret",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.Notify(ctx, "updated", resource+"("+id.String()+")"); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := url.QueryEscape("number eq '10000'")
+	if err := srv.Notify(ctx, "collection", resource+"?$filter="+filter); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fetched) != 2 || fetched[0] != "10000" || fetched[1] != "10000" {
+		t.Errorf("wanted the customer fetched twice, got %v", fetched)
+	}
+
+	// An error is a 500 so BC sends the notification again
+	failWith = errors.New("fail")
+	if err := srv.Notify(ctx, "updated", resource+"("+id.String()+")"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("wanted status 500 error, got %v", err)
+	}
+}
+
+func TestFetchWrongEntitySet(t *testing.T) {
+	srv := bcfake.NewServer()
+	defer srv.Close()
+
+	client, err := srv.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := bc.NewAPIPage[customer](client, "items")
+
+	n := webhook.Notification{
+		Resource:   client.SubscriptionResource("customers") + "(" + uuid.NewString() + ")",
+		ChangeType: webhook.ChangeUpdated,
+	}
+	if _, err := webhook.Fetch(context.Background(), items, n); err == nil {
+		t.Error("wanted error for another entity set")
+	}
+
+	n.ChangeType = webhook.ChangeDeleted
+	if _, err := webhook.Fetch(context.Background(), items, n); err == nil {
+		t.Error("wanted error for a deleted record")
+	}
+}
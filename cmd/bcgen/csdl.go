@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// edmx is the root of a $metadata CSDL document. Only the parts used by
+// the generator are decoded.
+type edmx struct {
+	XMLName xml.Name `xml:"Edmx"`
+	Schemas []schema `xml:"DataServices>Schema"`
+}
+
+type schema struct {
+	Namespace    string            `xml:"Namespace,attr"`
+	EnumTypes    []enumType        `xml:"EnumType"`
+	ComplexTypes []structType      `xml:"ComplexType"`
+	EntityTypes  []structType      `xml:"EntityType"`
+	Containers   []entityContainer `xml:"EntityContainer"`
+}
+
+type enumType struct {
+	Name    string       `xml:"Name,attr"`
+	Members []enumMember `xml:"Member"`
+}
+
+type enumMember struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// structType is an EntityType or a ComplexType.
+type structType struct {
+	Name                 string               `xml:"Name,attr"`
+	Keys                 []propertyRef        `xml:"Key>PropertyRef"`
+	Properties           []property           `xml:"Property"`
+	NavigationProperties []navigationProperty `xml:"NavigationProperty"`
+}
+
+type propertyRef struct {
+	Name string `xml:"Name,attr"`
+}
+
+type property struct {
+	Name      string `xml:"Name,attr"`
+	Type      string `xml:"Type,attr"`
+	Nullable  string `xml:"Nullable,attr"`
+	MaxLength string `xml:"MaxLength,attr"`
+}
+
+type navigationProperty struct {
+	Name string `xml:"Name,attr"`
+	Type string `xml:"Type,attr"`
+}
+
+type entityContainer struct {
+	Name       string      `xml:"Name,attr"`
+	EntitySets []entitySet `xml:"EntitySet"`
+}
+
+type entitySet struct {
+	Name       string `xml:"Name,attr"`
+	EntityType string `xml:"EntityType,attr"`
+}
+
+// parseMetadata decodes the CSDL document.
+func parseMetadata(data []byte) (edmx, error) {
+	var doc edmx
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("parse metadata: %w", err)
+	}
+
+	if len(doc.Schemas) == 0 {
+		return doc, fmt.Errorf("parse metadata: no schemas found")
+	}
+	return doc, nil
+}
+
+// isKey returns true if the property is part of the key.
+func (t structType) isKey(name string) bool {
+	for _, k := range t.Keys {
+		if k.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCollection returns the element type of "Collection(T)" and true,
+// or the type and false if it is not a collection.
+func splitCollection(typ string) (string, bool) {
+	if inner, ok := strings.CutPrefix(typ, "Collection("); ok {
+		return strings.TrimSuffix(inner, ")"), true
+	}
+	return typ, false
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// header marks the file as generated so linters and reviewers skip it.
+const header = "// Code generated by bcgen. DO NOT EDIT.\n\n"
+
+// edmTypes maps the primitive EDM types to Go types. Nullable dates use
+// *bc.Date because BC sends null for them and Date cannot unmarshal null.
+var edmTypes = map[string]string{
+	"Edm.String":         "string",
+	"Edm.Guid":           "uuid.UUID",
+	"Edm.Boolean":        "bool",
+	"Edm.Byte":           "int",
+	"Edm.Int16":          "int",
+	"Edm.Int32":          "int",
+	"Edm.Int64":          "int64",
+	"Edm.Decimal":        "float64",
+	"Edm.Double":         "float64",
+	"Edm.Single":         "float64",
+	"Edm.Date":           "bc.Date",
+	"Edm.DateTimeOffset": "time.Time",
+	"Edm.TimeOfDay":      "string",
+	"Edm.Duration":       "string",
+}
+
+// generator holds the type names resolved from the schemas.
+type generator struct {
+	doc     edmx
+	pkg     string
+	enums   map[string]string // qualified name -> Go name
+	structs map[string]string // qualified name -> Go name
+	buf     bytes.Buffer
+}
+
+// generate returns the formatted Go source for the metadata document.
+func generate(doc edmx, pkg string) ([]byte, error) {
+	g := &generator{
+		doc:     doc,
+		pkg:     pkg,
+		enums:   map[string]string{},
+		structs: map[string]string{},
+	}
+
+	for _, s := range doc.Schemas {
+		for _, e := range s.EnumTypes {
+			g.enums[s.Namespace+"."+e.Name] = exportName(e.Name)
+		}
+		for _, t := range s.ComplexTypes {
+			g.structs[s.Namespace+"."+t.Name] = exportName(t.Name)
+		}
+		for _, t := range s.EntityTypes {
+			g.structs[s.Namespace+"."+t.Name] = exportName(t.Name)
+		}
+	}
+
+	for _, s := range doc.Schemas {
+		for _, e := range s.EnumTypes {
+			g.writeEnum(e)
+		}
+		for _, t := range s.ComplexTypes {
+			g.writeStruct(t)
+		}
+		for _, t := range s.EntityTypes {
+			g.writeStruct(t)
+		}
+		for _, c := range s.Containers {
+			g.writeEntitySets(s.Namespace, c)
+		}
+	}
+
+	src := g.source()
+	formatted, err := format.Source(src)
+	if err != nil {
+		return src, fmt.Errorf("format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// source adds the header, package clause and the imports used by the body.
+func (g *generator) source() []byte {
+	body := g.buf.String()
+
+	var std, external []string
+	for _, imp := range []struct {
+		path     string
+		external bool
+	}{
+		{"fmt", false},
+		{"strings", false},
+		{"time", false},
+		{"unicode/utf8", false},
+		{"github.com/erlorenz/bc-go/bc", true},
+		{"github.com/google/uuid", true},
+	} {
+		used := regexp.MustCompile(`\b` + path.Base(imp.path) + `\.`)
+		if !used.MatchString(body) {
+			continue
+		}
+		if imp.external {
+			external = append(external, strconv.Quote(imp.path))
+		} else {
+			std = append(std, strconv.Quote(imp.path))
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString(header)
+	fmt.Fprintf(&src, "package %s\n\n", g.pkg)
+	if len(std)+len(external) > 0 {
+		fmt.Fprintf(&src, "import (\n%s\n\n%s\n)\n", strings.Join(std, "\n"), strings.Join(external, "\n"))
+	}
+	src.WriteString(body)
+	return src.Bytes()
+}
+
+// writeEnum writes a string type with a constant for each member. The
+// constant values are the escaped member names, e.g. "In_x0020_Review",
+// which BC also uses in JSON. The decoded name is used for the identifier.
+func (g *generator) writeEnum(e enumType) {
+	name := exportName(e.Name)
+	fmt.Fprintf(&g.buf, "\n// %s is the %s option field.\n", name, e.Name)
+	fmt.Fprintf(&g.buf, "type %s string\n\n", name)
+
+	if len(e.Members) == 0 {
+		return
+	}
+
+	seen := map[string]int{}
+	g.buf.WriteString("const (\n")
+	for _, m := range e.Members {
+		decoded := decodeName(m.Name)
+
+		ident := exportName(decoded)
+		if ident == "" {
+			ident = "Blank"
+		}
+		seen[ident]++
+		if n := seen[ident]; n > 1 {
+			ident += strconv.Itoa(n)
+		}
+
+		fmt.Fprintf(&g.buf, "%s%s %s = %s", name, ident, name, strconv.Quote(m.Name))
+		if decoded != m.Name {
+			fmt.Fprintf(&g.buf, " // %s", strconv.Quote(decoded))
+		}
+		g.buf.WriteString("\n")
+	}
+	g.buf.WriteString(")\n")
+}
+
+// writeStruct writes the struct, its expand constants and its Validate method.
+func (g *generator) writeStruct(t structType) {
+	name := exportName(t.Name)
+
+	fmt.Fprintf(&g.buf, "\n// %s is the %s type.\n", name, t.Name)
+	fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+	for _, p := range t.Properties {
+		typ, ok := g.propertyType(p)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&g.buf, "%s %s `json:%q`\n", exportName(p.Name), typ, p.Name)
+	}
+	for _, n := range t.NavigationProperties {
+		fmt.Fprintf(&g.buf, "%s %s `json:%q`\n", exportName(n.Name), g.navigationType(n), n.Name+",omitempty")
+	}
+	g.buf.WriteString("}\n")
+
+	if len(t.NavigationProperties) > 0 {
+		fmt.Fprintf(&g.buf, "\n// The navigation properties of %s to use with $expand.\n", name)
+		g.buf.WriteString("const (\n")
+		for _, n := range t.NavigationProperties {
+			fmt.Fprintf(&g.buf, "%sExpand%s = %q\n", name, exportName(n.Name), n.Name)
+		}
+		g.buf.WriteString(")\n")
+	}
+
+	g.writeValidate(name, t)
+}
+
+// writeValidate writes a Validate method that checks key and non-nullable
+// properties are set and strings are within their MaxLength.
+func (g *generator) writeValidate(name string, t structType) {
+	recv := strings.ToLower(name[:1])
+	var checks bytes.Buffer
+
+	for _, p := range t.Properties {
+		typ, ok := g.propertyType(p)
+		if !ok {
+			continue
+		}
+		field := recv + "." + exportName(p.Name)
+		required := t.isKey(p.Name) || p.Nullable == "false"
+
+		switch {
+		case required && typ == "uuid.UUID":
+			fmt.Fprintf(&checks, "if %s == uuid.Nil {\nerrs = append(errs, %q)\n}\n", field, p.Name+" is empty")
+		case required && (typ == "bc.Date" || typ == "time.Time"):
+			fmt.Fprintf(&checks, "if %s.IsZero() {\nerrs = append(errs, %q)\n}\n", field, p.Name+" is empty")
+		case required && typ == "string" && t.isKey(p.Name):
+			fmt.Fprintf(&checks, "if %s == \"\" {\nerrs = append(errs, %q)\n}\n", field, p.Name+" is empty")
+		}
+
+		if typ == "string" && p.MaxLength != "" && p.MaxLength != "max" {
+			fmt.Fprintf(&checks, "if utf8.RuneCountInString(%s) > %s {\nerrs = append(errs, %q)\n}\n",
+				field, p.MaxLength, p.Name+" is longer than "+p.MaxLength)
+		}
+	}
+
+	fmt.Fprintf(&g.buf, "\n// Validate implements the bc.Validator interface.\n")
+	if checks.Len() == 0 {
+		fmt.Fprintf(&g.buf, "func (%s) Validate() error {\nreturn nil\n}\n", name)
+		return
+	}
+
+	fmt.Fprintf(&g.buf, "func (%s %s) Validate() error {\nvar errs []string\n\n", recv, name)
+	g.buf.Write(checks.Bytes())
+	g.buf.WriteString("\nif len(errs) > 0 {\nreturn fmt.Errorf(\"validation: %s\", strings.Join(errs, \", \"))\n}\nreturn nil\n}\n")
+}
+
+// writeEntitySets writes a constructor for the APIPage of each entity set.
+func (g *generator) writeEntitySets(namespace string, c entityContainer) {
+	for _, set := range c.EntitySets {
+		typ, ok := g.structs[set.EntityType]
+		if !ok {
+			typ, ok = g.structs[namespace+"."+lastSegment(set.EntityType)]
+		}
+		if !ok {
+			continue
+		}
+
+		fn := "New" + exportName(set.Name) + "Page"
+		fmt.Fprintf(&g.buf, "\n// %s creates the APIPage for the %s entity set.\n", fn, set.Name)
+		fmt.Fprintf(&g.buf, "func %s(client *bc.Client) *bc.APIPage[%s] {\nreturn bc.NewAPIPage[%s](client, %q)\n}\n", fn, typ, typ, set.Name)
+	}
+}
+
+// propertyType returns the Go type of the property, or false if it is skipped.
+func (g *generator) propertyType(p property) (string, bool) {
+	elem, isCollection := splitCollection(p.Type)
+
+	// Media is read with the stream endpoints, not decoded
+	if elem == "Edm.Stream" {
+		return "", false
+	}
+
+	typ, ok := edmTypes[elem]
+	if !ok {
+		typ, ok = g.enums[elem]
+	}
+	if !ok {
+		typ, ok = g.structs[elem]
+	}
+	if !ok {
+		typ = "any"
+	}
+
+	if isCollection {
+		return "[]" + typ, true
+	}
+	if typ == "bc.Date" && p.Nullable != "false" {
+		return "*bc.Date", true
+	}
+	return typ, true
+}
+
+// navigationType returns a pointer for a single entity so that types can
+// reference each other, or a slice for a collection.
+func (g *generator) navigationType(n navigationProperty) string {
+	elem, isCollection := splitCollection(n.Type)
+
+	typ, ok := g.structs[elem]
+	if !ok {
+		typ = "map[string]any"
+	}
+
+	if isCollection {
+		return "[]" + typ
+	}
+	if !ok {
+		return typ
+	}
+	return "*" + typ
+}
+
+// escapeRe matches the _xHHHH_ sequences used to escape characters in CSDL names.
+var escapeRe = regexp.MustCompile(`_x([0-9A-Fa-f]{4})_`)
+
+// decodeName replaces the _xHHHH_ escapes with the characters, so
+// "Credit_x0020_Memo" becomes "Credit Memo".
+func decodeName(s string) string {
+	return escapeRe.ReplaceAllStringFunc(s, func(m string) string {
+		r, _ := strconv.ParseUint(m[2:6], 16, 32)
+		return string(rune(r))
+	})
+}
+
+// exportName turns a CSDL name into an exported Go identifier. Words are split
+// on any character that is not a letter or digit, and "id" is written as "ID".
+func exportName(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if w == "id" || w == "Id" {
+			b.WriteString("ID")
+			continue
+		}
+		if rest, ok := strings.CutSuffix(w, "Id"); ok {
+			w = rest + "ID"
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+// lastSegment returns the name without the namespace.
+func lastSegment(s string) string {
+	return s[strings.LastIndex(s, ".")+1:]
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile("testdata/metadata.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := parseMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(doc, "bcapi")
+	if err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "bcapi_gen.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %s", err)
+	}
+
+	got := string(src)
+	for _, want := range []string{
+		"// Code generated by bcgen. DO NOT EDIT.",
+		"package bcapi",
+		// Enums with escaped names keep the value BC uses in JSON
+		"type SalesOrderStatus string",
+		`SalesOrderStatusInReview SalesOrderStatus = "In_x0020_Review" // "In Review"`,
+		`ContactTypeBlank ContactType = "_x0020_" // " "`,
+		`ItemTypeNonInventory ItemType = "Non_x002D_Inventory" // "Non-Inventory"`,
+		`ItemTypeInventory ItemType = "Inventory"`,
+		// Property types
+		"ID uuid.UUID `json:\"id\"`",
+		"CustomerID uuid.UUID `json:\"customerId\"`",
+		"OrderDate *bc.Date `json:\"orderDate\"`",
+		"PostingDate bc.Date `json:\"postingDate\"`",
+		"TotalAmountIncludingTax float64 `json:\"totalAmountIncludingTax\"`",
+		"Status SalesOrderStatus `json:\"status\"`",
+		"ShipToAddress PostalAddressType `json:\"shipToAddress\"`",
+		"Tags []string `json:\"tags\"`",
+		"LastModifiedDateTime time.Time `json:\"lastModifiedDateTime\"`",
+		// Navigation properties
+		"Currency *Currency `json:\"currency,omitempty\"`",
+		"SalesOrderLines []SalesOrderLine `json:\"salesOrderLines,omitempty\"`",
+		`SalesOrderExpandSalesOrderLines = "salesOrderLines"`,
+		// Validate
+		"func (s SalesOrder) Validate() error",
+		"if s.ID == uuid.Nil",
+		"if utf8.RuneCountInString(s.Number) > 20",
+		"if s.PostingDate.IsZero()",
+		// Entity sets
+		"func NewSalesOrdersPage(client *bc.Client) *bc.APIPage[SalesOrder]",
+		`return bc.NewAPIPage[Currency](client, "currencies")`,
+	} {
+		if !strings.Contains(normalizeSpace(got), normalizeSpace(want)) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+
+	// Media is not decoded
+	if strings.Contains(got, "pdfDocument") {
+		t.Error("generated code contains the Edm.Stream property pdfDocument")
+	}
+}
+
+func TestParseMetadataInvalid(t *testing.T) {
+	if _, err := parseMetadata([]byte("<Edmx></Edmx>")); err == nil {
+		t.Error("expected error for metadata without schemas, got nil")
+	}
+
+	if _, err := parseMetadata([]byte("not xml")); err == nil {
+		t.Error("expected error for invalid XML, got nil")
+	}
+}
+
+func TestExportName(t *testing.T) {
+	tests := map[string]string{
+		"salesOrder":      "SalesOrder",
+		"id":              "ID",
+		"customerId":      "CustomerID",
+		"Credit Memo":     "CreditMemo",
+		"G/L Account":     "GLAccount",
+		"3rd Party":       "N3rdParty",
+		"postalAddressId": "PostalAddressID",
+	}
+
+	for input, want := range tests {
+		if got := exportName(input); got != want {
+			t.Errorf("exportName(%q): wanted %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestDecodeName(t *testing.T) {
+	if got := decodeName("Credit_x0020_Memo"); got != "Credit Memo" {
+		t.Errorf("wanted %q, got %q", "Credit Memo", got)
+	}
+	if got := decodeName("G_x002F_L_x0020_Account"); got != "G/L Account" {
+		t.Errorf("wanted %q, got %q", "G/L Account", got)
+	}
+}
+
+// normalizeSpace collapses the alignment added by gofmt.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
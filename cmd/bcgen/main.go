@@ -0,0 +1,118 @@
+// Command bcgen generates Go types for a Business Central API endpoint from
+// its $metadata CSDL document.
+//
+// For each entity and complex type it generates a struct with JSON tags and a
+// Validate method built from the key, nullability and MaxLength facets. Option
+// fields become string types with a constant for each value, navigation
+// properties get Expand constants and every entity set gets a constructor
+// returning a typed *bc.APIPage.
+//
+// Check the metadata in and generate from the file for reproducible builds:
+//
+//	//go:generate go run github.com/erlorenz/bc-go/cmd/bcgen -metadata metadata.xml -package bcapi -out bcapi_gen.go
+//
+// Use -fetch to download the metadata of an endpoint instead. The credentials
+// are read from the TENANT_ID, CLIENT_ID, CLIENT_SECRET and ENVIRONMENT
+// environment variables or a .env file. Combine it with -save to update the
+// checked-in file:
+//
+//	bcgen -fetch -endpoint v2.0 -save metadata.xml -package bcapi -out bcapi_gen.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/erlorenz/bc-go/bc"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "bcgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	metadataFile := flag.String("metadata", "", "read the $metadata XML from this file")
+	fetch := flag.Bool("fetch", false, "download the $metadata from BC")
+	endpoint := flag.String("endpoint", "v2.0", "the APIEndpoint to download the $metadata for")
+	envFile := flag.String("env", ".env", "the .env file with the credentials used by -fetch")
+	save := flag.String("save", "", "write the downloaded $metadata to this file")
+	out := flag.String("out", "", "write the generated code to this file instead of stdout")
+	pkg := flag.String("package", "bcapi", "the package name of the generated code")
+	flag.Parse()
+
+	if (*metadataFile == "") == !*fetch {
+		return errors.New("exactly one of -metadata or -fetch is required")
+	}
+
+	var data []byte
+	var err error
+	if *fetch {
+		data, err = fetchMetadata(*envFile, *endpoint)
+		if err != nil {
+			return err
+		}
+		if *save != "" {
+			if err := os.WriteFile(*save, data, 0o644); err != nil {
+				return fmt.Errorf("save metadata: %w", err)
+			}
+		}
+	} else {
+		data, err = os.ReadFile(*metadataFile)
+		if err != nil {
+			return fmt.Errorf("read metadata: %w", err)
+		}
+	}
+
+	doc, err := parseMetadata(data)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(doc, *pkg)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
+
+// fetchMetadata downloads the $metadata of the endpoint. The company does not
+// matter for $metadata so COMPANY_ID is optional.
+func fetchMetadata(envFile, endpoint string) ([]byte, error) {
+	godotenv.Load(envFile)
+
+	config := bc.ClientConfig{
+		TenantID:     os.Getenv("TENANT_ID"),
+		ClientID:     os.Getenv("CLIENT_ID"),
+		ClientSecret: os.Getenv("CLIENT_SECRET"),
+		Environment:  os.Getenv("ENVIRONMENT"),
+		CompanyID:    os.Getenv("COMPANY_ID"),
+		APIEndpoint:  endpoint,
+	}
+	if config.CompanyID == "" {
+		config.CompanyID = uuid.Nil.String()
+	}
+
+	client, err := bc.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return client.Metadata(ctx)
+}
@@ -10,6 +10,7 @@ require (
 	github.com/go-playground/validator/v10 v10.28.0
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	software.sslmate.com/src/go-pkcs12 v0.7.3
 )
 
 require (
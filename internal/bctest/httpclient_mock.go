@@ -14,3 +14,10 @@ func (mt MockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 
 	return mt.Response, nil
 }
+
+// RoundTripFunc lets a function be used as an http.RoundTripper.
+type RoundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
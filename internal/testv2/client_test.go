@@ -47,10 +47,7 @@ func (il ItemList) Validate() error {
 
 func TestV2Client_GetItems(t *testing.T) {
 	t.Parallel()
-	client, err := bc.NewClient(testConfig)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := newClient(t)
 
 	req, err := client.NewRequest(context.Background(), bc.RequestOptions{
 		Method:        "GET",
@@ -79,10 +76,7 @@ func TestV2Client_GetItems(t *testing.T) {
 
 func TestV2Client_GetItemsAPIError(t *testing.T) {
 	t.Parallel()
-	client, err := bc.NewClient(testConfig)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := newClient(t)
 
 	req, err := client.NewRequest(context.Background(), bc.RequestOptions{
 		Method:        "GET",
@@ -128,7 +122,7 @@ func TestV2Client_GetItemsNetworkError(t *testing.T) {
 		Error: errors.New("network_error"),
 	}}
 
-	client, err := bc.NewClient(testConfig, bc.WithHTTPClient(mhc))
+	client, err := bc.NewClient(testConfig, append(authOptions(), bc.WithHTTPClient(mhc))...)
 	if err != nil {
 		t.Fatal(err)
 	}
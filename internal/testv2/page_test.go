@@ -2,11 +2,12 @@ package testv2
 
 import (
 	"context"
-	"fmt"
-	"math/rand/v2"
+	"strings"
 	"testing"
 
 	"github.com/erlorenz/bc-go/bc"
+	"github.com/erlorenz/bc-go/bc/cassette"
+	"github.com/google/uuid"
 )
 
 func TestAPIPage_Panic(t *testing.T) {
@@ -18,7 +19,7 @@ func TestAPIPage_Panic(t *testing.T) {
 		}
 	}()
 
-	client, err := bc.NewClient(testConfig)
+	client, err := bc.NewClient(testConfig, authOptions()...)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,10 +32,7 @@ func TestAPIPage_Get(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 
-	client, err := bc.NewClient(testConfig)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := newClient(t)
 
 	itemsPage := bc.NewAPIPage[Item](client, "items")
 
@@ -73,12 +71,16 @@ func TestAPIPage_New(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 
-	client, err := bc.NewClient(testConfig)
-	if err != nil {
-		t.Fatal(err)
+	// A unique number when recording, so it does not collide with an item
+	// left by an earlier run. It is scrubbed so the cassette has a fixed one.
+	num := cassetteItemNumber
+	var scrubs []cassette.Option
+	if recording {
+		num = "T" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:19])
+		scrubs = append(scrubs, cassette.WithScrub(num, cassetteItemNumber))
 	}
 
-	num := fmt.Sprintf("TESTTEST%d", rand.IntN(100))
+	client := newClient(t, scrubs...)
 
 	body := map[string]any{
 		"number":       num,